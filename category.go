@@ -0,0 +1,76 @@
+package goerr
+
+import "errors"
+
+// Category classifies an error. Each Category is also an error, so that
+// errors.Is reports whether an error chain includes an *Error with that
+// category:
+//
+//	if errors.Is(err, goerr.CategoryNetwork) {
+//		// retry
+//	}
+type Category int
+
+const (
+	// CategoryNone is the category of errors that have not been classified.
+	CategoryNone Category = iota
+
+	// CategoryValidation is the category of errors caused by invalid input.
+	CategoryValidation
+
+	// CategoryIO is the category of errors caused by input or output
+	// operations, such as reading or writing files.
+	CategoryIO
+
+	// CategoryNetwork is the category of errors caused by network operations.
+	CategoryNetwork
+
+	// CategoryInternal is the category of errors caused by a defect in the
+	// program.
+	CategoryInternal
+)
+
+// Error returns the name of the category.
+func (c Category) Error() string {
+	switch c {
+	case CategoryNone:
+		return "none"
+	case CategoryValidation:
+		return "validation"
+	case CategoryIO:
+		return "io"
+	case CategoryNetwork:
+		return "network"
+	case CategoryInternal:
+		return "internal"
+	default:
+		return "unknown category"
+	}
+}
+
+type categorizer interface{ Category() Category }
+
+// Category returns the category stored in this instance, or, if nothing
+// stored in this instance, the category of the first error in the chain of
+// the wrapped error that provides one.
+func (e Error) Category() Category {
+	if e.category != CategoryNone {
+		return e.category
+	}
+	var c categorizer
+	if errors.As(e.err, &c) {
+		return c.Category()
+	}
+	return CategoryNone
+}
+
+// WithCategory stores category as the value to be returned by the Category
+// method, and to be matched by errors.Is.
+func (e *Error) WithCategory(category Category) *Error {
+	if e == nil {
+		return nil
+	}
+	e.modified()
+	e.category = category
+	return e
+}
@@ -0,0 +1,60 @@
+package goerr_test
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/karrick/goerr"
+)
+
+func TestCategory(t *testing.T) {
+	t.Run("sans category", func(t *testing.T) {
+		err := goerr.New("some error message")
+
+		if got, want := err.Category(), goerr.CategoryNone; got != want {
+			t.Errorf("GOT: %v; WANT: %v", got, want)
+		}
+		if got, want := errors.Is(err, goerr.CategoryNetwork), false; got != want {
+			t.Errorf("GOT: %v; WANT: %v", got, want)
+		}
+		if got, want := errors.Is(err, goerr.CategoryNone), false; got != want {
+			t.Errorf("GOT: %v; WANT: %v", got, want)
+		}
+	})
+
+	t.Run("with category", func(t *testing.T) {
+		err := goerr.New("some error message").WithCategory(goerr.CategoryNetwork)
+
+		if got, want := err.Category(), goerr.CategoryNetwork; got != want {
+			t.Errorf("GOT: %v; WANT: %v", got, want)
+		}
+		if got, want := errors.Is(err, goerr.CategoryNetwork), true; got != want {
+			t.Errorf("GOT: %v; WANT: %v", got, want)
+		}
+		if got, want := errors.Is(err, goerr.CategoryIO), false; got != want {
+			t.Errorf("GOT: %v; WANT: %v", got, want)
+		}
+	})
+
+	t.Run("through wrap", func(t *testing.T) {
+		inner := goerr.New("connection refused").WithCategory(goerr.CategoryNetwork)
+		err := goerr.Wrapf(fmt.Errorf("dial: %w", inner), "cannot fetch")
+
+		if got, want := err.Category(), goerr.CategoryNetwork; got != want {
+			t.Errorf("GOT: %v; WANT: %v", got, want)
+		}
+		if got, want := errors.Is(err, goerr.CategoryNetwork), true; got != want {
+			t.Errorf("GOT: %v; WANT: %v", got, want)
+		}
+		if got, want := errors.Is(err, goerr.CategoryValidation), false; got != want {
+			t.Errorf("GOT: %v; WANT: %v", got, want)
+		}
+	})
+
+	t.Run("Error", func(t *testing.T) {
+		if got, want := goerr.CategoryIO.Error(), "io"; got != want {
+			t.Errorf("GOT: %q; WANT: %q", got, want)
+		}
+	})
+}
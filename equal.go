@@ -0,0 +1,42 @@
+package goerr
+
+import "errors"
+
+// EqualSemantic returns true when a and b have the same message, exit code,
+// temporary value, and wrapped error message, ignoring the lines displayed
+// before the message, between the message and options, and after the
+// options, along with the options and their comments. It is useful in tests
+// that should not depend on how an error is decorated. The message of an
+// *Error is its own message, without that of the error it wraps, while the
+// message of any other error is the result of its Error method. The exit code
+// and temporary value of an *Error are resolved by its ExitCode and Temporary
+// methods, so those of the error it wraps are used when it has none of its own.
+func EqualSemantic(a, b error) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	am, ac := semanticMessages(a)
+	bm, bc := semanticMessages(b)
+	return am == bm && ac == bc &&
+		errorExitCode(a) == errorExitCode(b) &&
+		errorTemporary(a) == errorTemporary(b)
+}
+
+// semanticMessages returns the message of err and that of the error it wraps,
+// or the empty string when it wraps none.
+func semanticMessages(err error) (string, string) {
+	var message, cause string
+	var wrapped error
+	if tv, ok := err.(*Error); ok {
+		if tv == nil {
+			return "", ""
+		}
+		message, wrapped = tv.ownMessage(), tv.err
+	} else {
+		message, wrapped = err.Error(), errors.Unwrap(err)
+	}
+	if wrapped != nil {
+		cause = wrapped.Error()
+	}
+	return message, cause
+}
@@ -0,0 +1,62 @@
+package goerr_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/karrick/goerr"
+)
+
+func TestEqualSemantic(t *testing.T) {
+	base := errors.New("permission denied")
+
+	a := goerr.Wrapf(base, "cannot open config").
+		WithExitCode(77).
+		WithLineBeforeMessage("while starting").
+		WithOptions([]string{"app", "--config", "app.toml"}).
+		WithOptionComment(2, "this file")
+	b := goerr.Wrapf(base, "cannot open config").
+		WithExitCode(77).
+		WithLineAfterOptions("see usage")
+
+	t.Run("differ only in decorations", func(t *testing.T) {
+		if got, want := goerr.EqualSemantic(a, b), true; got != want {
+			t.Errorf("GOT: %v; WANT: %v", got, want)
+		}
+	})
+
+	t.Run("differ in exit code", func(t *testing.T) {
+		c := goerr.Wrapf(base, "cannot open config").WithExitCode(1)
+
+		if got, want := goerr.EqualSemantic(a, c), false; got != want {
+			t.Errorf("GOT: %v; WANT: %v", got, want)
+		}
+	})
+
+	t.Run("differ only in wrapped exit code", func(t *testing.T) {
+		e5 := goerr.New("cannot open config").WithExitCode(5)
+		e7 := goerr.New("cannot open config").WithExitCode(7)
+
+		if got, want := goerr.EqualSemantic(goerr.Wrapf(e5, "m"), goerr.Wrapf(e7, "m")), false; got != want {
+			t.Errorf("GOT: %v; WANT: %v", got, want)
+		}
+	})
+
+	t.Run("differ only in wrapped temporary", func(t *testing.T) {
+		t1 := goerr.New("cannot open config").WithTemporary(true)
+		t2 := goerr.New("cannot open config")
+
+		if got, want := goerr.EqualSemantic(goerr.Wrapf(t1, "m"), goerr.Wrapf(t2, "m")), false; got != want {
+			t.Errorf("GOT: %v; WANT: %v", got, want)
+		}
+	})
+
+	t.Run("nil", func(t *testing.T) {
+		if got, want := goerr.EqualSemantic(nil, nil), true; got != want {
+			t.Errorf("GOT: %v; WANT: %v", got, want)
+		}
+		if got, want := goerr.EqualSemantic(a, nil), false; got != want {
+			t.Errorf("GOT: %v; WANT: %v", got, want)
+		}
+	})
+}
@@ -1,51 +1,199 @@
 package goerr
 
 import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
 	"fmt"
+	"io"
+	"regexp"
+	"runtime"
 	"sort"
+	"strconv"
 	"strings"
+	"sync"
+	"time"
+	"unicode"
+	"unicode/utf8"
 )
 
+// CauseSeparator is the string placed between an error message and the
+// message of the error it wraps.
+var CauseSeparator = ": "
+
+// CaretFormatter, when not nil, is invoked to build the line for each option
+// comment, given the column and width of the options it refers to, instead of
+// the default line of a caret followed by tildes and the comment. It is not
+// consulted when carets are disabled or comments are displayed as a legend.
+var CaretFormatter func(col, width int, comment string) string
+
+// CaptureWrapSite causes Wrap and Wrapf to record the name of the function
+// that invoked them, which is then returned by the WrapSite method.
+var CaptureWrapSite bool
+
+// MaxRenderDepth limits how many wrapped errors ErrorLinesVerbose displays.
+// When it is 0, all wrapped errors are displayed.
+var MaxRenderDepth int
+
+// StrictOptionIndices causes Validate to report option comments whose index
+// does not refer to one of the options. Otherwise such comments are displayed
+// after the end of the options line.
+var StrictOptionIndices bool
+
+// WarningPrefix is displayed before the message of an Error created by
+// WithWarning. It may be set to the empty string to display the message
+// without a prefix.
+var WarningPrefix = "warning: "
+
+// now returns the current time, and may be replaced by tests.
+var now = time.Now
+
 // Error holds contextual information about the error, including an optional
 // exit code, whether the error is considered temporary, a wrapped error, and
 // options and option comments for displaying an error string.
 type Error struct {
 	optionComments           []optionComment
 	options                  []string
+	sectionOrder             []Section
 	beforeMessage            []string
 	betweenMessageAndOptions []string
 	afterOptions             []string
+	temporaryUntil           time.Time
+	cache                    *linesCache
 	err                      error
+	sentinel                 error
+	messageFunc              func() string
+	exitCodeFunc             func() int
+	commentConnector         string
+	helpURL                  string
+	messagePrefix            string
+	messageSuffix            string
 	msg                      string
+	programName              string
+	wrapSite                 string
+	category                 Category
+	commandPrefix            int
 	exitCode                 int
+	maxLines                 int
+	maxWidth                 int
+	truncateCols             int
+	collapseDuplicateCause   bool
+	commentLegend            bool
+	commentsAscending        bool
+	isCommentConnectorSet    bool
 	isExitCodeSet            bool
+	noCarets                 bool
+	quoteOptions             bool
+	showExitCode             bool
+	trimComments             bool
+	warning                  bool
+	stopCodeInheritance      bool
 	temporary                bool
 	isTemporarySet           bool
 }
 
+// linesCache holds the lines returned by ErrorLines once they are rendered,
+// when caching is enabled by WithLinesCache.
+type linesCache struct {
+	once  sync.Once
+	lines []string
+}
+
+// OptionIndex is the index of one of the options of an Error, which makes
+// call sites of WithOptionCommentIdx self-documenting.
+type OptionIndex int
+
+// OptionAnnotation is a comment about one of the options of an Error, as
+// returned by the Annotations method.
+type OptionAnnotation struct {
+	Index   int    // index of the option to which Comment refers
+	Comment string // comment displayed for the option
+}
+
+// String returns the index and comment of the annotation, such as
+// "2: for this option".
+func (a OptionAnnotation) String() string {
+	return strconv.Itoa(a.Index) + ": " + a.Comment
+}
+
 type optionComment struct {
 	comment string
 	index   int
+	offset  int  // when partial, runes into the option where the span begins
+	length  int  // when partial, runes in the span
+	partial bool // underline only part of the option at index
+	wide    bool // underline at least as many columns as the comment
+	all     bool // underline all options rather than the option at index
+	gap     bool // point at the space after the option at index
 }
 
 type optionCommentSlice []optionComment
 
-func (s optionCommentSlice) Len() int           { return len(s) }
-func (x optionCommentSlice) Less(i, j int) bool { return x[i].index > x[j].index }
-func (x optionCommentSlice) Swap(i, j int)      { x[i], x[j] = x[j], x[i] }
+func (s optionCommentSlice) Len() int { return len(s) }
+func (x optionCommentSlice) Less(i, j int) bool {
+	if x[i].all != x[j].all {
+		return x[j].all // comments for all options sort after the others
+	}
+	if x[i].index != x[j].index {
+		return x[i].index > x[j].index
+	}
+	if x[i].gap != x[j].gap {
+		return x[i].gap // gap follows the option, so sorts before it
+	}
+	return x[i].offset > x[j].offset
+}
+func (x optionCommentSlice) Swap(i, j int) { x[i], x[j] = x[j], x[i] }
+
+// Section identifies a group of lines in the error message.
+type Section int
+
+const (
+	// SectionBeforeMessage is the lines before the message.
+	SectionBeforeMessage Section = iota
+
+	// SectionMessage is the message line.
+	SectionMessage
+
+	// SectionBetweenMessageAndOptions is the lines between the message and
+	// the option lines.
+	SectionBetweenMessageAndOptions
+
+	// SectionOptions is the options line followed by any option comment
+	// lines.
+	SectionOptions
+
+	// SectionAfterOptions is the lines after the option lines.
+	SectionAfterOptions
+
+	sectionCount // number of sections
+)
+
+var defaultSectionOrder = []Section{
+	SectionBeforeMessage,
+	SectionMessage,
+	SectionBetweenMessageAndOptions,
+	SectionOptions,
+	SectionAfterOptions,
+}
 
 // New returns a new Error with a formatted message.
 func New(f string, a ...any) *Error {
 	return &Error{msg: fmt.Sprintf(f, a...)}
 }
 
+// NewLiteral returns a new Error with msg as its message, which unlike New is
+// not treated as a format string, so it may contain a literal '%'.
+func NewLiteral(msg string) *Error {
+	return &Error{msg: msg}
+}
+
 // Wrap returns nil when err is nil; otherwise returns a new Error that wraps
 // err.
 func Wrap(err error) *Error {
 	if err == nil {
 		return nil
 	}
-	return &Error{err: err}
+	return &Error{err: err, wrapSite: captureWrapSite()}
 }
 
 // Wrapf returns nil when err is nil; otherwise returns a new formatted Error
@@ -54,78 +202,1046 @@ func Wrapf(err error, f string, a ...any) *Error {
 	if err == nil {
 		return nil
 	}
-	return &Error{err: err, msg: fmt.Sprintf(f, a...)}
+	return &Error{err: err, msg: fmt.Sprintf(f, a...), wrapSite: captureWrapSite()}
+}
+
+// WrapSentinel returns nil when err is nil; otherwise returns a new formatted
+// Error that wraps err, and for which errors.Is also reports true when given
+// sentinel.
+func WrapSentinel(err, sentinel error, f string, a ...any) *Error {
+	if err == nil {
+		return nil
+	}
+	return &Error{err: err, sentinel: sentinel, msg: fmt.Sprintf(f, a...), wrapSite: captureWrapSite()}
+}
+
+// WrapAuto returns nil when err is nil; otherwise returns a new Error that
+// wraps err, whose message names the function that invoked WrapAuto, such as
+// "loadConfig failed", to avoid writing such messages by hand.
+func WrapAuto(err error) *Error {
+	if err == nil {
+		return nil
+	}
+	name := "function"
+	if pc, _, _, ok := runtime.Caller(1); ok {
+		if fn := runtime.FuncForPC(pc); fn != nil {
+			name = shortFuncName(fn.Name())
+		}
+	}
+	return &Error{err: err, msg: name + " failed", wrapSite: captureWrapSite()}
+}
+
+// WrapFunc returns nil when err is nil; otherwise returns a new Error that
+// wraps err, after passing it to fn to be configured. Unlike Wrap, the result
+// is an error, so a nil result is a nil error rather than a nil *Error.
+//
+//	return goerr.WrapFunc(err, func(e *goerr.Error) {
+//	    e.WithExitCode(2).WithTemporary(true)
+//	})
+func WrapFunc(err error, fn func(*Error)) error {
+	if err == nil {
+		return nil
+	}
+	e := &Error{err: err, wrapSite: captureWrapSite()}
+	fn(e)
+	return e
+}
+
+// captureWrapSite returns the name of the function that invoked the function
+// that invoked it, when CaptureWrapSite is true.
+func captureWrapSite() string {
+	if !CaptureWrapSite {
+		return ""
+	}
+	pc, _, _, ok := runtime.Caller(2)
+	if !ok {
+		return ""
+	}
+	if fn := runtime.FuncForPC(pc); fn != nil {
+		return fn.Name()
+	}
+	return ""
+}
+
+// shortFuncName returns the name of a function reported by the runtime without
+// its package path, so "example.com/app.loadConfig" becomes "loadConfig", and
+// "example.com/app.(*Server).Start" becomes "(*Server).Start".
+func shortFuncName(name string) string {
+	if i := strings.LastIndexByte(name, '/'); i >= 0 {
+		name = name[i+1:]
+	}
+	if i := strings.IndexByte(name, '.'); i >= 0 {
+		name = name[i+1:]
+	}
+	return name
 }
 
-// Error returns an error message suitable for display.
-func (e Error) Error() string {
+// AccumulateExitCode stores the greater of code and any exit code already
+// stored as the value to be returned by the ExitCode method, so that after a
+// series of failed operations, the highest exit code is kept.
+func (e *Error) AccumulateExitCode(code int) *Error {
+	if e == nil {
+		return nil
+	}
+	e.modified()
+	if !e.isExitCodeSet || code > e.exitCode {
+		e.exitCode = code
+	}
+	e.isExitCodeSet = true
+	return e
+}
+
+// Annotations returns the option comments stored in this instance, in the
+// order in which they were added. Comments added by WithOptionCommentAll are
+// not included.
+func (e Error) Annotations() []OptionAnnotation {
+	var annotations []OptionAnnotation
+	for _, oc := range e.optionComments {
+		if !oc.all {
+			annotations = append(annotations, OptionAnnotation{Index: oc.index, Comment: oc.comment})
+		}
+	}
+	return annotations
+}
+
+// Append appends the lines displayed before the message, between the message
+// and options, and after the options of other to those of this instance, and
+// likewise appends the options and option comments of other. The indices of
+// the appended option comments are offset by the number of options this
+// instance had, and adjusted for the command path of each instance declared
+// by WithCommandPrefix, so they continue to refer to the same options. It does
+// nothing when either the receiver or other is nil.
+func (e *Error) Append(other *Error) *Error {
+	if e == nil || other == nil {
+		return e
+	}
+	e.modified()
+
+	offset := len(e.options) - e.commandPath() + other.commandPath()
+
+	e.beforeMessage = appendLines(e.beforeMessage, other.beforeMessage)
+	e.betweenMessageAndOptions = appendLines(e.betweenMessageAndOptions, other.betweenMessageAndOptions)
+	e.afterOptions = appendLines(e.afterOptions, other.afterOptions)
+	e.options = appendLines(e.options, other.options)
+
+	n := len(e.optionComments)
+	e.optionComments = e.optionComments[:n:n] // never modify a shared array
+	for _, oc := range other.optionComments {
+		oc.index += offset
+		e.optionComments = append(e.optionComments, oc)
+	}
+
+	return e
+}
+
+// appendLines returns a slice with the elements of a followed by those of b,
+// without modifying the array underlying a.
+func appendLines(a, b []string) []string {
+	n := len(a)
+	return append(a[:n:n], b...)
+}
+
+// Bare returns a copy of this instance without the lines displayed before the
+// message, between the message and options, and after the options, and
+// without options or option comments. The message, wrapped error, exit code,
+// and temporary values are preserved. It returns nil when the receiver is nil.
+func (e *Error) Bare() *Error {
+	if e == nil {
+		return nil
+	}
+	bare := *e
+	bare.modified()
+	bare.beforeMessage = nil
+	bare.betweenMessageAndOptions = nil
+	bare.options = nil
+	bare.optionComments = nil
+	bare.afterOptions = nil
+	return &bare
+}
+
+// Coerce returns nil when err is nil, err itself when it is an *Error, and
+// otherwise a new Error that wraps err.
+func Coerce(err error) *Error {
+	switch tv := err.(type) {
+	case nil:
+		return nil
+	case *Error:
+		return tv
+	default:
+		return &Error{err: err}
+	}
+}
+
+// FromExitError returns nil when err is nil; otherwise returns a new Error
+// that wraps err. When err is or wraps an error that implements the ExitCode
+// method, such as *exec.ExitError, the new Error stores its exit code.
+func FromExitError(err error) *Error {
+	if err == nil {
+		return nil
+	}
+	e := &Error{err: err}
+	var ec exitCoder
+	if errors.As(err, &ec) {
+		e.isExitCodeSet = true
+		e.exitCode = ec.ExitCode()
+	}
+	return e
+}
+
+// FromRecover returns nil when r is nil; otherwise returns a new Error with
+// the exit code of code that wraps r, which is a value returned by recover.
+// When r is an error it is wrapped directly, and otherwise it is formatted
+// into a new error. When CaptureWrapSite is true, the function that invoked
+// FromRecover is stored as the wrap site.
+//
+//	defer func() {
+//	    if err := goerr.FromRecover(recover(), 70); err != nil {
+//	        // handle err
+//	    }
+//	}()
+func FromRecover(r any, code int) *Error {
+	if r == nil {
+		return nil
+	}
+	err, ok := r.(error)
+	if !ok {
+		err = fmt.Errorf("panic: %v", r)
+	}
+	return &Error{err: err, exitCode: code, isExitCodeSet: true, wrapSite: captureWrapSite()}
+}
+
+// Merge returns a new Error that wraps primary and displays the message of
+// secondary on a line after any option lines. The exit code and temporary
+// values of the new Error are taken from primary when it provides them, and
+// otherwise from secondary, each resolved the way the ExitCode and Temporary
+// methods of Error resolve them. When either error is nil, the other is wrapped
+// without an additional line, and when both are nil, Merge returns nil.
+func Merge(primary, secondary error) *Error {
+	if primary == nil {
+		primary, secondary = secondary, nil
+		if primary == nil {
+			return nil
+		}
+	}
+
+	e := &Error{err: primary}
+
+	e.exitCode, e.isExitCodeSet = resolveExitCode(primary)
+	e.temporary, e.isTemporarySet = resolveTemporary(primary)
+
+	if secondary != nil {
+		if !e.isExitCodeSet {
+			e.exitCode, e.isExitCodeSet = resolveExitCode(secondary)
+		}
+		if !e.isTemporarySet {
+			e.temporary, e.isTemporarySet = resolveTemporary(secondary)
+		}
+		e.afterOptions = append(e.afterOptions, secondary.Error())
+	}
+
+	return e
+}
+
+// Tee returns err after invoking logfn with err when err is not nil, so an
+// error may be logged where it is returned. A nil *Error, such as returned by
+// Wrapf when its error is nil, is treated as nil, so Tee returns a nil error
+// without invoking logfn.
+//
+//	return goerr.Tee(goerr.Wrapf(err, "cannot configure"), func(err error) {
+//	    logger.Print(err)
+//	})
+func Tee(err error, logfn func(error)) error {
+	if err == nil {
+		return nil
+	}
+	if tv, ok := err.(*Error); ok && tv == nil {
+		return nil
+	}
+	logfn(err)
+	return err
+}
+
+// Error returns an error message suitable for display. It has a pointer
+// receiver so that, like the builder methods, it may be invoked on a nil
+// Error, in which case it returns "<nil>".
+func (e *Error) Error() string {
+	if e == nil {
+		return "<nil>"
+	}
+	if len(e.beforeMessage) == 0 && len(e.betweenMessageAndOptions) == 0 &&
+		len(e.options) == 0 && len(e.afterOptions) == 0 && e.helpURL == "" &&
+		!e.showExitCode {
+		// Fast path for the common case of a single line error message.
+		return e.message()
+	}
 	return strings.Join(e.ErrorLines(), "\n")
 }
 
-// ErrorLines returns error message lines suitable for display.
-func (e Error) ErrorLines() []string {
-	lines := append([]string(nil), e.beforeMessage...)
+// message returns the error message line, which includes the message of the
+// wrapped error surrounded by any message prefix and suffix, and is prefixed
+// by the program name when set.
+func (e Error) message() string {
+	msg := e.ownMessage()
 
-	if e.msg != "" {
+	var message string
+	if msg != "" {
 		if e.err != nil {
-			lines = append(lines, e.msg+": "+e.err.Error())
+			message = e.err.Error()
+			if !e.collapseDuplicateCause || message != msg {
+				message = msg + CauseSeparator + message
+			}
 		} else {
-			lines = append(lines, e.msg)
+			message = msg
 		}
+	} else if e.err != nil {
+		message = e.err.Error()
 	} else {
-		if e.err != nil {
-			lines = append(lines, e.err.Error())
-		} else {
-			lines = append(lines, "error without message or wrapped error") // upstream bug
+		message = "error without message or wrapped error" // upstream bug
+	}
+	message = e.messagePrefix + message + e.messageSuffix
+	if e.warning {
+		message = WarningPrefix + message
+	}
+	if e.programName != "" {
+		message = e.programName + ": " + message
+	}
+	return message
+}
+
+// modified discards any lines cached by ErrorLines, and is invoked by each
+// method that modifies this instance.
+func (e *Error) modified() {
+	if e.cache != nil {
+		e.cache = new(linesCache)
+	}
+}
+
+// ownMessage returns the message of this instance, without the message of
+// the wrapped error.
+func (e Error) ownMessage() string {
+	if e.messageFunc != nil {
+		return e.messageFunc()
+	}
+	return e.msg
+}
+
+// ErrorLines returns error message lines suitable for display, or nil when
+// invoked on a nil Error. When caching is enabled by WithLinesCache, the lines
+// are only rendered once, and the same slice is returned until the instance
+// is modified, so it must not be modified by the caller.
+func (e *Error) ErrorLines() []string {
+	if e == nil {
+		return nil
+	}
+	if c := e.cache; c != nil {
+		c.once.Do(func() { c.lines = e.renderLines() })
+		return c.lines
+	}
+	return e.renderLines()
+}
+
+// renderLines returns the lines of the error message, in display order.
+func (e Error) renderLines() []string {
+	sections, _ := e.sections()
+
+	var lines []string
+	for _, section := range sections {
+		lines = append(lines, section...)
+	}
+	return lines
+}
+
+// sections returns the lines of the error message grouped by section, in
+// display order, along with the Section of each group. When the lines are
+// truncated, a group holding the truncation indicator follows, then a group
+// holding the help URL line when one is set, and when the exit code is shown,
+// a group holding the exit code line is last. The Section of each of those
+// groups is sectionCount. The help URL and exit code lines count towards the
+// limit set by WithMaxLines, and may be omitted like the other lines.
+func (e Error) sections() ([][]string, []Section) {
+	sections := [][]string{
+		e.beforeMessage,
+		{e.message()},
+		e.betweenMessageAndOptions,
+		e.optionLines(),
+		e.afterOptions,
+	}
+
+	for _, section := range []Section{SectionBeforeMessage, SectionBetweenMessageAndOptions, SectionAfterOptions} {
+		if e.trimComments {
+			sections[section] = trimLines(sections[section])
+		}
+		if e.truncateCols > 0 {
+			sections[section] = truncateLines(sections[section], e.truncateCols)
+		}
+	}
+
+	// The help URL and exit code lines follow the sections in display order.
+	if e.helpURL != "" {
+		sections = append(sections, []string{"See: " + e.helpURL})
+	}
+	if e.showExitCode {
+		if code := e.ExitCode(); code != 0 {
+			sections = append(sections, []string{fmt.Sprintf("exit code: %d", code)})
+		}
+	}
+
+	var indicator []string
+	if e.maxLines > 0 {
+		sections, indicator = truncateSections(sections, e.maxLines)
+	}
+
+	order := defaultSectionOrder
+	if e.sectionOrder != nil {
+		order = orderSections(e.sectionOrder)
+	}
+
+	ordered := make([][]string, 0, len(sections))
+	for _, section := range order {
+		ordered = append(ordered, sections[section])
+	}
+	if indicator != nil {
+		ordered = append(ordered, indicator)
+		order = append(order[:len(order):len(order)], sectionCount)
+	}
+	for _, trailer := range sections[sectionCount:] {
+		if len(trailer) > 0 {
+			ordered = append(ordered, trailer)
+			order = append(order[:len(order):len(order)], sectionCount)
 		}
 	}
 
-	lines = append(lines, e.betweenMessageAndOptions...)
+	return ordered, order
+}
+
+// ErrorLinesVerbose returns the lines of ErrorLines followed by the lines of
+// each error in the chain of wrapped errors. The first line of each wrapped
+// error is prefixed by "caused by: ", and its remaining lines are indented.
+// Wrapped errors other than *Error are displayed on a single line. When
+// MaxRenderDepth is greater than 0, at most that many wrapped errors are
+// displayed, followed by a line indicating there are more.
+func (e Error) ErrorLinesVerbose() []string {
+	lines := e.ErrorLines()
 
-	// Append option comment lines.
-	lines = append(lines, optionLines(e.options, e.optionComments...)...)
+	err := e.err
+	for depth := 0; err != nil && depth < maxUnwrapDepth; depth++ {
+		if MaxRenderDepth > 0 && depth == MaxRenderDepth {
+			return append(lines, "... (more causes)")
+		}
+
+		tv, ok := err.(*Error)
+		if !ok || tv == nil {
+			lines = append(lines, "caused by: "+err.Error())
+			err = Cause(err)
+			continue
+		}
 
-	// Append additional lines.
-	lines = append(lines, e.afterOptions...)
+		for i, line := range tv.ErrorLines() {
+			if i == 0 {
+				lines = append(lines, "caused by: "+line)
+			} else {
+				lines = append(lines, "    "+line)
+			}
+		}
+		err = tv.err
+	}
 
 	return lines
 }
 
+// orderSections returns every section exactly once, with those in order
+// first, followed by those missing from order in their default order.
+// Sections repeated in order are only included at their first position.
+func orderSections(order []Section) []Section {
+	ordered := make([]Section, 0, sectionCount)
+	var seen [sectionCount]bool
+
+	for _, o := range [][]Section{order, defaultSectionOrder} {
+		for _, section := range o {
+			if section < 0 || section >= sectionCount || seen[section] {
+				continue
+			}
+			seen[section] = true
+			ordered = append(ordered, section)
+		}
+	}
+
+	return ordered
+}
+
+// truncateSections returns sections limited to at most limit lines in total,
+// including the returned indicator line that reports how many lines were
+// omitted, which is nil when no lines were omitted. The message and option
+// lines are kept in preference to the other sections, which are kept in
+// order. When limit is 1, only the message is kept, without an indicator.
+func truncateSections(sections [][]string, limit int) ([][]string, []string) {
+	var total int
+	for _, section := range sections {
+		total += len(section)
+	}
+	if total <= limit {
+		return sections, nil
+	}
+
+	budget := max(limit-1, 1) // reserve a line for the indicator, but always keep the message
+
+	truncated := make([][]string, len(sections))
+
+	// Message first, then options, then remaining sections in display order.
+	priority := []int{1, 3, 0, 2, 4}
+	for i := int(sectionCount); i < len(sections); i++ {
+		priority = append(priority, i)
+	}
+	for _, i := range priority {
+		n := min(len(sections[i]), budget)
+		truncated[i] = sections[i][:n]
+		budget -= n
+	}
+
+	if limit < 2 {
+		return truncated, nil
+	}
+
+	omitted := total
+	for _, section := range truncated {
+		omitted -= len(section)
+	}
+	return truncated, []string{fmt.Sprintf("... (%d more lines)", omitted)}
+}
+
 // ExitCode returns the exit code stored in this instance, or, if nothing
 // stored in this instance, the result of invoking ExitCode on the possibly
 // wrapped error, recursing until either a wrapped error implements ExitCode
 // method, does not implement Unwrap, or nil error.
 func (e Error) ExitCode() int {
-	if e.isExitCodeSet {
-		return e.exitCode
+	if code, ok := e.ownExitCode(); ok {
+		return code
 	}
 	return ExitCode(e.err)
 }
 
+// Fingerprint returns the hexadecimal SHA-256 hash of the message and exit
+// code stored by WithExitCode of this instance, and of the message of each
+// error in its chain of wrapped errors, so equivalent errors have the same
+// fingerprint, which may be used to deduplicate them. Decorative lines,
+// options, and other attributes, including an exit code computed by the
+// function stored by WithExitCodeFunc, do not contribute to the fingerprint.
+func (e Error) Fingerprint() string {
+	h := sha256.New()
+	fmt.Fprintf(h, "%q %d %t", e.ownMessage(), e.exitCode, e.isExitCodeSet)
+	walk(e.err, func(err error) bool {
+		if tv, ok := err.(*Error); ok {
+			fmt.Fprintf(h, " %q", tv.ownMessage())
+		} else {
+			fmt.Fprintf(h, " %q", err.Error())
+		}
+		return true
+	})
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// Format implements fmt.Formatter. The %+v verb displays the lines of
+// ErrorLinesVerbose, so each wrapped error is displayed on its own line
+// prefixed by "caused by: ", and the %#v verb displays the result of
+// GoString. Otherwise the %v and %s verbs display the result of Error, and
+// the %q verb displays it quoted.
+func (e *Error) Format(f fmt.State, verb rune) {
+	if e == nil {
+		io.WriteString(f, "<nil>")
+		return
+	}
+	switch verb {
+	case 'v':
+		switch {
+		case f.Flag('+'):
+			io.WriteString(f, strings.Join(e.ErrorLinesVerbose(), "\n"))
+		case f.Flag('#'):
+			io.WriteString(f, e.GoString())
+		default:
+			io.WriteString(f, e.Error())
+		}
+	case 's':
+		io.WriteString(f, e.Error())
+	case 'q':
+		fmt.Fprintf(f, "%q", e.Error())
+	default:
+		fmt.Fprintf(f, "%%!%c(*goerr.Error=%s)", verb, e.Error())
+	}
+}
+
+// GoString returns a representation of every field of this instance, including
+// whether the exit code and temporary values have been set, to aid debugging.
+// It is invoked when formatting with the %#v verb.
+func (e Error) GoString() string {
+	type fields Error // fields has the same layout but no GoString method
+	return "goerr.Error" + strings.TrimPrefix(fmt.Sprintf("%#v", fields(e)), "goerr.fields")
+}
+
+// HasAnnotations returns true when the lines of this instance include lines
+// that annotate the options line by aligning markers under it, such as
+// carets, which are only legible when displayed in a monospace font. It
+// returns false when there are no options or option comments, or when carets
+// are disabled by WithCarets.
+func (e Error) HasAnnotations() bool {
+	return len(e.options) > 0 && len(e.optionComments) > 0 && !e.noCarets
+}
+
+// HasDuplicateCommentIndices returns true when more than one option comment
+// refers to the same option, which is often a mistake. Comments added by
+// WithOptionCommentAt, WithOptionCommentAll, and WithGapComment are not
+// considered, because they refer to part of an option, all options, or the
+// space between options.
+func (e Error) HasDuplicateCommentIndices() bool {
+	seen := make(map[int]struct{}, len(e.optionComments))
+	for _, oc := range e.optionComments {
+		if oc.partial || oc.all || oc.gap {
+			continue
+		}
+		if _, ok := seen[oc.index]; ok {
+			return true
+		}
+		seen[oc.index] = struct{}{}
+	}
+	return false
+}
+
+// HelpURL returns the URL of documentation about this error stored by
+// WithHelpURL, or when none is stored, that of the first error in the chain of
+// wrapped errors that provides one, or the empty string.
+func (e Error) HelpURL() string {
+	if e.helpURL != "" {
+		return e.helpURL
+	}
+	var url string
+	walk(e.err, func(err error) bool {
+		switch tv := err.(type) {
+		case *Error:
+			url = tv.helpURL
+		case helpURLer:
+			url = tv.HelpURL()
+		}
+		return url == ""
+	})
+	return url
+}
+
+// Is returns true when target is the category stored by WithCategory, or
+// matches the sentinel error stored by WrapSentinel. It is invoked by
+// errors.Is, which also examines the wrapped error.
+func (e Error) Is(target error) bool {
+	if c, ok := target.(Category); ok && c != CategoryNone {
+		return e.category == c
+	}
+	return e.sentinel != nil && errors.Is(e.sentinel, target)
+}
+
+// MaxLineWidth returns the number of columns occupied by the widest of the
+// lines returned by ErrorLines, ignoring any ANSI SGR escape sequences and
+// counting East Asian wide and fullwidth runes, such as CJK ideographs, as two
+// columns, which is useful for drawing a border around the error.
+func (e Error) MaxLineWidth() int {
+	var width int
+	for _, line := range e.ErrorLines() {
+		width = max(width, displayWidth(line))
+	}
+	return width
+}
+
+// NumberedLines returns the lines of ErrorLines, each prefixed by its line
+// number, so a particular line can be referred to. Lines that annotate the
+// options line, such as those with carets, are not numbered, but are indented
+// by the width of the prefix so they remain aligned with the options line.
+func (e Error) NumberedLines() []string {
+	sections, order := e.sections()
+
+	var count int
+	for i, section := range sections {
+		count += len(section)
+		if order[i] == SectionOptions && len(section) > 0 {
+			count -= len(section) - 1
+		}
+	}
+	width := len(strconv.Itoa(count))
+	indent := strings.Repeat(" ", width+2)
+
+	var lines []string
+	var number int
+	for i, section := range sections {
+		for j, line := range section {
+			if order[i] == SectionOptions && j > 0 {
+				lines = append(lines, indent+line)
+				continue
+			}
+			number++
+			lines = append(lines, fmt.Sprintf("%*d: %s", width, number, line))
+		}
+	}
+	return lines
+}
+
+// OptionAnnotationLines returns only the options line followed by the lines of
+// any option comments, without the message or other lines, so callers may
+// compose their own layouts. It returns nil when there are no options.
+func (e Error) OptionAnnotationLines() []string {
+	return e.optionLines()
+}
+
+// OptionRuler returns a line to be displayed beneath the options line that
+// marks the column where each option begins with '|', and fills the other
+// columns with '-', ending with a '|' after the last option, such as
+// "|----|--|" for the options "zero" and "one". It aids diagnosing the
+// alignment of option comments, and returns the empty string when there are
+// no options.
+func (e Error) OptionRuler() string {
+	if len(e.options) == 0 {
+		return ""
+	}
+
+	var ruler strings.Builder
+	for i, opt := range e.options {
+		if e.quoteOptions {
+			opt = shellQuote(opt)
+		}
+		width := displayWidth(opt)
+		if i == len(e.options)-1 {
+			width = max(width-1, 0) // no separator follows the last option
+		}
+		ruler.WriteByte('|')
+		ruler.WriteString(strings.Repeat("-", width))
+	}
+	ruler.WriteByte('|')
+	return ruler.String()
+}
+
+// OptionComments returns a copy of the option comments stored in this
+// instance, keyed by the index of the option to which each refers. When more
+// than one comment refers to the same index, the most recently added one is
+// returned. Comments added by WithOptionCommentAll are not included.
+func (e Error) OptionComments() map[int]string {
+	comments := make(map[int]string, len(e.optionComments))
+	for _, oc := range e.optionComments {
+		if !oc.all {
+			comments[oc.index] = oc.comment
+		}
+	}
+	return comments
+}
+
+// Options returns a copy of the options stored in this instance.
+func (e Error) Options() []string {
+	return append([]string(nil), e.options...)
+}
+
+// Plain returns an error whose message is the same as this instance, but which
+// carries none of its other attributes and does not wrap any error. It
+// returns nil when the receiver is nil.
+func (e *Error) Plain() error {
+	if e == nil {
+		return nil
+	}
+	return errors.New(e.Error())
+}
+
 // Temporary returns the exit code stored in this instance, or, if nothing
 // stored in this instance, the result of invoking Temporary on the possibly
 // wrapped error, recursing until either a wrapped error implements Temporary
 // method, does not implement Unwrap, or nil error.
 func (e Error) Temporary() bool {
 	if e.isTemporarySet {
-		return e.temporary
+		return e.ownTemporary()
 	}
 	return Temporary(e.err)
 }
 
+// ownTemporary returns the temporary value stored in this instance, which is
+// false after the deadline stored by WithTemporaryUntil has passed.
+func (e Error) ownTemporary() bool {
+	if e.temporary && !e.temporaryUntil.IsZero() {
+		return now().Before(e.temporaryUntil)
+	}
+	return e.temporary
+}
+
+// ownExitCode returns the exit code of this instance, which is computed by its
+// exit code function when one is set, and whether either is set. When neither
+// is set but WithStopCodeInheritance was invoked, it returns 0 as though set.
+func (e Error) ownExitCode() (int, bool) {
+	if e.exitCodeFunc != nil {
+		return e.exitCodeFunc(), true
+	}
+	if !e.isExitCodeSet && e.stopCodeInheritance {
+		return 0, true
+	}
+	return e.exitCode, e.isExitCodeSet
+}
+
 // Unwrap returns the encapsulated error, or nil.
 func (e Error) Unwrap() error {
 	return e.err
 }
 
+// Validate returns an error describing the first inconsistency found in the
+// attributes of this instance, or nil when none is found. It checks that:
+//
+//  1. an exit code, when set, is in the range 0 through 255, the range of exit
+//     statuses portable across operating systems; and
+//  2. an error marked temporary does not also set a nonzero exit code, which
+//     would request the process to exit for a condition that may succeed when
+//     retried;
+//  3. each section in the section order is valid and appears at most once;
+//     and
+//  4. when StrictOptionIndices is true, each option comment refers to one of
+//     the options, and no two option comments refer to the same option, as
+//     reported by HasDuplicateCommentIndices.
+func (e Error) Validate() error {
+	if e.isExitCodeSet && (e.exitCode < 0 || e.exitCode > 255) {
+		return New("exit code out of range 0 through 255: %d", e.exitCode)
+	}
+	if e.isTemporarySet && e.temporary && e.isExitCodeSet && e.exitCode != 0 {
+		return New("temporary error with nonzero exit code: %d", e.exitCode)
+	}
+	var seen [sectionCount]bool
+	for _, section := range e.sectionOrder {
+		if section < 0 || section >= sectionCount {
+			return New("invalid section: %d", section)
+		}
+		if seen[section] {
+			return New("section repeated in order: %d", section)
+		}
+		seen[section] = true
+	}
+	if e.commandPrefix > len(e.options) {
+		return New("command prefix exceeds the number of options %d: %d", len(e.options), e.commandPrefix)
+	}
+	if StrictOptionIndices {
+		arguments := len(e.options) - e.commandPath()
+		for _, oc := range e.optionComments {
+			if !oc.all && (oc.index < 0 || oc.index >= arguments) {
+				return New("option comment index out of range 0 through %d: %d", arguments-1, oc.index)
+			}
+		}
+		if e.HasDuplicateCommentIndices() {
+			return New("more than one option comment refers to the same option")
+		}
+	}
+	return nil
+}
+
+// WrapSite returns the name of the function that created this instance by
+// invoking Wrap or Wrapf, or the empty string when CaptureWrapSite was false.
+func (e Error) WrapSite() string {
+	return e.wrapSite
+}
+
+// WithCarets controls whether option comments are displayed on lines with
+// carets that underline the options they refer to, which is the default. When
+// carets is false, each option comment is instead displayed on a line that
+// begins with the option text, which remains readable when the error is not
+// displayed in a monospace font.
+func (e *Error) WithCarets(carets bool) *Error {
+	if e == nil {
+		return nil
+	}
+	e.modified()
+	e.noCarets = !carets
+	return e
+}
+
+// WithCause stores err as the wrapped error, which is returned by Unwrap.
+func (e *Error) WithCause(err error) *Error {
+	if e == nil {
+		return nil
+	}
+	e.modified()
+	e.err = err
+	return e
+}
+
+// WithCollapseDuplicateCause controls whether the message of the wrapped
+// error is omitted from the error message when it is the same as the message
+// of this instance, so that degenerate chains do not display the same text
+// twice.
+func (e *Error) WithCollapseDuplicateCause(collapse bool) *Error {
+	if e == nil {
+		return nil
+	}
+	e.modified()
+	e.collapseDuplicateCause = collapse
+	return e
+}
+
+// WithCommandPrefix declares that the first count options are the command
+// path, such as the program name and a subcommand, and that the indices of
+// option comments refer to the options that follow it, so index 0 refers to
+// the first argument after the command path. A negative count is treated as
+// 0, and Validate reports a count greater than the number of options, which
+// is otherwise treated as the number of options.
+//
+//	goerr.New("unknown flag").
+//	    WithOptions([]string{"git", "commit", "--amned"}).
+//	    WithCommandPrefix(2).
+//	    WithOptionComment(0, "did you mean --amend?")
+func (e *Error) WithCommandPrefix(count int) *Error {
+	if e == nil {
+		return nil
+	}
+	e.modified()
+	e.commandPrefix = max(count, 0)
+	return e
+}
+
+// commandPath returns the number of options that are the command path, which
+// is never more than the number of options.
+func (e Error) commandPath() int {
+	return min(e.commandPrefix, len(e.options))
+}
+
+// WithCommentConnector stores connector as the string displayed between the
+// underline of an option and its comment, which by default is a single space.
+func (e *Error) WithCommentConnector(connector string) *Error {
+	if e == nil {
+		return nil
+	}
+	e.modified()
+	e.isCommentConnectorSet = true
+	e.commentConnector = connector
+	return e
+}
+
+// WithCommentLegend controls whether option comments are displayed as a
+// legend. When legend is true, a numbered marker is displayed under each
+// option that has a comment, and the comments are displayed after the
+// markers, each prefixed by its number. This remains readable when many
+// options have comments.
+func (e *Error) WithCommentLegend(legend bool) *Error {
+	if e == nil {
+		return nil
+	}
+	e.modified()
+	e.commentLegend = legend
+	return e
+}
+
+// WithCommentsAscending controls whether option comments are displayed in the
+// order of the options they refer to, so the comment of the first option is
+// displayed first, rather than the default order in which the comment of the
+// last option is displayed first.
+func (e *Error) WithCommentsAscending(ascending bool) *Error {
+	if e == nil {
+		return nil
+	}
+	e.modified()
+	e.commentsAscending = ascending
+	return e
+}
+
+// WithContextStack displays lines before the message in reverse order, and
+// before any lines already displayed before the message, so the last line
+// appears first. It models a breadcrumb of context that grows as an error is
+// returned up the call stack, where the innermost context is added first but
+// the outermost should be read first.
+func (e *Error) WithContextStack(lines []string) *Error {
+	if e == nil {
+		return nil
+	}
+	e.modified()
+	stack := make([]string, 0, len(lines)+len(e.beforeMessage))
+	for i := len(lines) - 1; i >= 0; i-- {
+		stack = append(stack, lines[i])
+	}
+	e.beforeMessage = append(stack, e.beforeMessage...)
+	return e
+}
+
+// WithErrorf stores a formatted message and, when err is not nil, stores err
+// as the wrapped error.
+func (e *Error) WithErrorf(err error, f string, a ...any) *Error {
+	if e == nil {
+		return nil
+	}
+	e.modified()
+	if err != nil {
+		e.err = err
+	}
+	e.msg = fmt.Sprintf(f, a...)
+	return e
+}
+
 // WithExitCode stores code as the value to be returned by the ExitCode
 // method.
 func (e *Error) WithExitCode(code int) *Error {
 	if e == nil {
 		return nil
 	}
-	e.isExitCodeSet = true
-	e.exitCode = code
+	e.modified()
+	e.isExitCodeSet = true
+	e.exitCode = code
+	return e
+}
+
+// WithExitCodeClamp stores code, limited to the range 0 through 255, as the
+// value to be returned by the ExitCode method. Unlike WithExitCode, whose
+// out of range values are reported by Validate, an out of range code is
+// replaced by the nearest value within the range.
+func (e *Error) WithExitCodeClamp(code int) *Error {
+	return e.WithExitCode(min(max(code, 0), 255))
+}
+
+// WithExitCodeFrom stores the exit code and temporary values of other,
+// including whether each was set and whether other stops exit code
+// inheritance, so this instance resolves them the same way other does.
+func (e *Error) WithExitCodeFrom(other *Error) *Error {
+	if e == nil || other == nil {
+		return e
+	}
+	e.modified()
+	e.exitCode, e.isExitCodeSet = other.exitCode, other.isExitCodeSet
+	e.exitCodeFunc = other.exitCodeFunc
+	e.stopCodeInheritance = other.stopCodeInheritance
+	e.temporary, e.isTemporarySet = other.temporary, other.isTemporarySet
+	e.temporaryUntil = other.temporaryUntil
+	return e
+}
+
+// WithExitCodeFunc stores fn to compute the value to be returned by the
+// ExitCode method, which takes precedence over an exit code stored by
+// WithExitCode. The result of fn is not cached, so fn is invoked each time the
+// exit code is resolved, allowing it to depend on state at exit time. When fn
+// is nil, any previously stored function is removed.
+func (e *Error) WithExitCodeFunc(fn func() int) *Error {
+	if e == nil {
+		return nil
+	}
+	e.modified()
+	e.exitCodeFunc = fn
+	return e
+}
+
+// WithGapComment causes an additional error message line to be printed with a
+// caret that points at the space following the option indexed by afterIndex,
+// with comment, such as to indicate where a missing argument is expected.
+func (e *Error) WithGapComment(afterIndex int, comment string) *Error {
+	if e == nil {
+		return nil
+	}
+	e.modified()
+	e.optionComments = append(e.optionComments, optionComment{
+		comment: comment,
+		index:   afterIndex,
+		gap:     true,
+	})
+	return e
+}
+
+// WithHelpURL stores url as the location of documentation about this error,
+// which is returned by the HelpURL method, and displayed on a line prefixed by
+// "See: " after the other lines of the error.
+func (e *Error) WithHelpURL(url string) *Error {
+	if e == nil {
+		return nil
+	}
+	e.modified()
+	e.helpURL = url
 	return e
 }
 
@@ -135,6 +1251,7 @@ func (e *Error) WithLineAfterOptions(line string) *Error {
 	if e == nil {
 		return nil
 	}
+	e.modified()
 	e.afterOptions = append(e.afterOptions, line)
 	return e
 }
@@ -145,6 +1262,7 @@ func (e *Error) WithLinesAfterOptions(lines []string) *Error {
 	if e == nil {
 		return nil
 	}
+	e.modified()
 	e.afterOptions = append(e.afterOptions, lines...)
 	return e
 }
@@ -155,6 +1273,7 @@ func (e *Error) WithLineBeforeMessage(line string) *Error {
 	if e == nil {
 		return nil
 	}
+	e.modified()
 	e.beforeMessage = append(e.beforeMessage, line)
 	return e
 }
@@ -165,6 +1284,7 @@ func (e *Error) WithLinesBeforeMessage(lines []string) *Error {
 	if e == nil {
 		return nil
 	}
+	e.modified()
 	e.beforeMessage = append(e.beforeMessage, lines...)
 	return e
 }
@@ -175,6 +1295,7 @@ func (e *Error) WithLineBetweenMessageAndOption(line string) *Error {
 	if e == nil {
 		return nil
 	}
+	e.modified()
 	e.betweenMessageAndOptions = append(e.betweenMessageAndOptions, line)
 	return e
 }
@@ -185,50 +1306,386 @@ func (e *Error) WithLinesBetweenMessageAndOption(lines []string) *Error {
 	if e == nil {
 		return nil
 	}
+	e.modified()
 	e.betweenMessageAndOptions = append(e.betweenMessageAndOptions, lines...)
 	return e
 }
 
+// WithLinesCache controls whether the lines returned by ErrorLines are
+// rendered only once and then cached, which is useful for an error that is
+// displayed many times, such as in a retry log. The cache is discarded when a
+// method modifies this instance, but caching assumes the error is otherwise
+// finalized: the results of functions stored by WithMessageFunc and
+// WithExitCodeFunc, and the errors this instance wraps, are not expected to
+// change once cached.
+func (e *Error) WithLinesCache(cache bool) *Error {
+	if e == nil {
+		return nil
+	}
+	e.cache = nil
+	if cache {
+		e.cache = new(linesCache)
+	}
+	return e
+}
+
+// WithMaxLines limits the number of lines returned by ErrorLines to limit,
+// including any help URL and exit code lines. When more lines would be
+// returned, the message and option lines are kept in preference to the other
+// lines, and a line reports how many lines were omitted, except that when limit
+// is 1 only the message is returned. A limit of 0 or less means no limit.
+func (e *Error) WithMaxLines(limit int) *Error {
+	if e == nil {
+		return nil
+	}
+	e.modified()
+	e.maxLines = limit
+	return e
+}
+
+// WithMessageFunc stores fn to be invoked each time the error message is
+// displayed to obtain the message, such as to translate it into the current
+// locale. When fn is not nil, it takes precedence over any formatted message.
+func (e *Error) WithMessageFunc(fn func() string) *Error {
+	if e == nil {
+		return nil
+	}
+	e.modified()
+	e.messageFunc = fn
+	return e
+}
+
+// WithMessageLiteral stores msg as the message, which unlike WithErrorf is not
+// treated as a format string, so it may contain a literal '%'.
+func (e *Error) WithMessageLiteral(msg string) *Error {
+	if e == nil {
+		return nil
+	}
+	e.modified()
+	e.msg = msg
+	return e
+}
+
+// WithMessagePrefix stores prefix to be displayed before the error message,
+// on the same line.
+func (e *Error) WithMessagePrefix(prefix string) *Error {
+	if e == nil {
+		return nil
+	}
+	e.modified()
+	e.messagePrefix = prefix
+	return e
+}
+
+// WithMessageSuffix stores suffix to be displayed after the error message,
+// including the message of any wrapped error, on the same line.
+func (e *Error) WithMessageSuffix(suffix string) *Error {
+	if e == nil {
+		return nil
+	}
+	e.modified()
+	e.messageSuffix = suffix
+	return e
+}
+
+// WithOption appends opt to the options to be printed when printing the error
+// message.
+func (e *Error) WithOption(opt string) *Error {
+	return e.WithOptionsAppend(opt)
+}
+
+// WithMaxWidth causes option comments to be wrapped so that, where possible,
+// their lines are at most width columns wide. The continuation lines of a
+// comment are indented to align with the start of the comment. A width of 0
+// or less means comments are not wrapped.
+func (e *Error) WithMaxWidth(width int) *Error {
+	if e == nil {
+		return nil
+	}
+	e.modified()
+	e.maxWidth = width
+	return e
+}
+
 // WithOptionComment causes an additional error message line to be printed
-// that underlines the option indexed by index, with comment.
+// that underlines the option indexed by index, with comment. When comment is
+// empty, the line only underlines the option.
 func (e *Error) WithOptionComment(index int, comment string) *Error {
 	if e == nil {
 		return nil
 	}
+	e.modified()
+	e.optionComments = append(e.optionComments, optionComment{
+		comment: comment,
+		index:   index,
+	})
+	return e
+}
+
+// WithOptionCommentAt causes an additional error message line to be printed
+// that underlines length runes of the option indexed by index, beginning
+// offset runes into that option, with comment. The offset and length are
+// clamped to remain within the option.
+func (e *Error) WithOptionCommentAt(index, offset, length int, comment string) *Error {
+	if e == nil {
+		return nil
+	}
+	e.modified()
+	e.optionComments = append(e.optionComments, optionComment{
+		comment: comment,
+		index:   index,
+		offset:  offset,
+		length:  length,
+		partial: true,
+	})
+	return e
+}
+
+// WithOptionCommentIdx is like WithOptionComment, but takes an OptionIndex,
+// so the index cannot be confused with another int parameter.
+func (e *Error) WithOptionCommentIdx(index OptionIndex, comment string) *Error {
+	return e.WithOptionComment(int(index), comment)
+}
+
+// WithOptionCommentAll causes an additional error message line to be printed
+// that underlines all of the options, with comment.
+func (e *Error) WithOptionCommentAll(comment string) *Error {
+	if e == nil {
+		return nil
+	}
+	e.modified()
+	e.optionComments = append(e.optionComments, optionComment{
+		comment: comment,
+		all:     true,
+	})
+	return e
+}
+
+// WithOptionCommentWide causes an additional error message line to be printed
+// that underlines the option indexed by index, with comment, like
+// WithOptionComment, except the underline spans at least as many columns as
+// the comment. Because each comment is printed on its own line, an underline
+// that extends beyond its option under the following options does not
+// interfere with their underlines.
+func (e *Error) WithOptionCommentWide(index int, comment string) *Error {
+	if e == nil {
+		return nil
+	}
+	e.modified()
 	e.optionComments = append(e.optionComments, optionComment{
 		comment: comment,
 		index:   index,
+		wide:    true,
 	})
 	return e
 }
 
+// WithOptionSuggestion causes an additional error message line to be printed
+// with a caret under the option indexed by index, and a comment suggesting
+// suggestion in its place, such as "did you mean --verbose?", which is
+// convenient when a command line flag is misspelled.
+func (e *Error) WithOptionSuggestion(index int, suggestion string) *Error {
+	return e.WithOptionComment(index, "did you mean "+suggestion+"?")
+}
+
 // WithOptions stores the options to be printed when printing the error
 // message.
 func (e *Error) WithOptions(options []string) *Error {
 	if e == nil {
 		return nil
 	}
+	e.modified()
 	e.options = options
 	return e
 }
 
+// WithOptionsAppend appends opts to the options to be printed when printing
+// the error message. Because options are only appended, the indices of
+// existing option comments remain valid.
+func (e *Error) WithOptionsAppend(opts ...string) *Error {
+	if e == nil {
+		return nil
+	}
+	e.modified()
+	n := len(e.options)
+	e.options = append(e.options[:n:n], opts...) // never modify caller's slice
+	return e
+}
+
+// WithOptionsQuoted controls whether options are quoted for a POSIX shell
+// when displayed, which removes the ambiguity of options that are empty or
+// contain whitespace or characters special to the shell. Option comments are
+// aligned with the quoted options.
+func (e *Error) WithOptionsQuoted(quoted bool) *Error {
+	if e == nil {
+		return nil
+	}
+	e.modified()
+	e.quoteOptions = quoted
+	return e
+}
+
+// WithProgramName stores name to be displayed before the error message, in
+// the conventional form "name: message". Other lines of the error message are
+// not prefixed.
+func (e *Error) WithProgramName(name string) *Error {
+	if e == nil {
+		return nil
+	}
+	e.modified()
+	e.programName = name
+	return e
+}
+
+// WithReplaceOptions stores a copy of options to be printed when printing the
+// error message, and removes any option comments, which referred to the
+// options being replaced.
+func (e *Error) WithReplaceOptions(options []string) *Error {
+	if e == nil {
+		return nil
+	}
+	e.modified()
+	e.options = append([]string(nil), options...)
+	e.optionComments = nil
+	return e
+}
+
+// WithSectionOrder stores the order in which the sections of the error
+// message are displayed. Sections missing from order are displayed after
+// those in order, in their default order, and a section that appears more
+// than once in order is only displayed at its first position, which Validate
+// reports.
+func (e *Error) WithSectionOrder(order []Section) *Error {
+	if e == nil {
+		return nil
+	}
+	e.modified()
+	e.sectionOrder = append([]Section(nil), order...)
+	return e
+}
+
+// WithShowExitCode controls whether the lines of the error end with a line
+// that displays the exit code, such as "exit code: 13", which is convenient
+// for scripts that scan standard error. The line is only displayed when the
+// exit code is not 0.
+func (e *Error) WithShowExitCode(show bool) *Error {
+	if e == nil {
+		return nil
+	}
+	e.modified()
+	e.showExitCode = show
+	return e
+}
+
+// WithSnippet appends line to the list of lines to include after any option
+// lines in the error message, followed by a line that underlines width columns
+// of it starting at column col and displays comment, as returned by
+// CaretLine. It annotates text other than command line options, such as a
+// line of a configuration file.
+func (e *Error) WithSnippet(line string, col, width int, comment string) *Error {
+	return e.WithLinesAfterOptions([]string{line, CaretLine(line, col, width, comment)})
+}
+
+// WithStopCodeInheritance causes this instance to resolve to an exit code of 0
+// rather than to the exit code of the error it wraps, when it has no exit code
+// of its own, which allows a boundary to reset the exit code of the errors it
+// wraps.
+func (e *Error) WithStopCodeInheritance() *Error {
+	if e == nil {
+		return nil
+	}
+	e.modified()
+	e.stopCodeInheritance = true
+	return e
+}
+
 // WithTemporary stores temporary as the value to be returned by the Temporary
 // method.
 func (e *Error) WithTemporary(temporary bool) *Error {
 	if e == nil {
 		return nil
 	}
+	e.modified()
 	e.isTemporarySet = true
 	e.temporary = temporary
+	e.temporaryUntil = time.Time{}
+	return e
+}
+
+// WithTemporaryUntil causes the Temporary method to return true until the
+// deadline, and false afterwards, modeling a condition that is only expected
+// to be transient for a limited time.
+func (e *Error) WithTemporaryUntil(deadline time.Time) *Error {
+	if e == nil {
+		return nil
+	}
+	e.modified()
+	e.isTemporarySet = true
+	e.temporary = true
+	e.temporaryUntil = deadline
+	return e
+}
+
+// WithTrimComments controls whether trailing whitespace is removed from
+// option comments and from the lines displayed before the message, between
+// the message and options, and after the options, when they are displayed.
+// By default they are displayed as given.
+func (e *Error) WithTrimComments(trim bool) *Error {
+	if e == nil {
+		return nil
+	}
+	e.modified()
+	e.trimComments = trim
+	return e
+}
+
+// WithTruncate causes the lines displayed before the message, between the
+// message and options, and after the options to be truncated to at most cols
+// columns, ending with an ellipsis, rather than extending past the width of
+// the terminal. The options line and the lines that annotate it are not
+// truncated, so they remain aligned. A cols of 0 disables truncation.
+func (e *Error) WithTruncate(cols int) *Error {
+	if e == nil {
+		return nil
+	}
+	e.modified()
+	e.truncateCols = cols
 	return e
 }
 
-func optionLines(opts []string, ocs ...optionComment) []string {
+// WithWarning stores msg as the message and marks this instance as a warning
+// rather than a failure, so IsWarning returns true for it and its message is
+// displayed after WarningPrefix. Unless an exit code is stored in this
+// instance, its exit code is 0, rather than that of the error it wraps.
+func (e *Error) WithWarning(msg string) *Error {
+	if e == nil {
+		return nil
+	}
+	e.modified()
+	e.msg = msg
+	e.warning = true
+	e.stopCodeInheritance = true
+	return e
+}
+
+// optionLines returns the options line followed by a line for each option
+// comment.
+func (e Error) optionLines() []string {
 	// zero one --two three
 	//                ^~~~~ cannot find this file
 	//          ^~~~~ for this option
 	//      ^~~ for this sub-command
 
+	opts, ocs := e.options, e.optionComments
+
+	if e.quoteOptions {
+		quoted := make([]string, len(opts))
+		for i, opt := range opts {
+			quoted[i] = shellQuote(opt)
+		}
+		opts = quoted
+	}
+
 	optCount := len(opts)
 	if optCount == 0 {
 		return nil
@@ -246,29 +1703,411 @@ func optionLines(opts []string, ocs ...optionComment) []string {
 	var length int
 
 	for _, opt := range opts {
-		length += len(opt) + 1
+		length += displayWidth(opt) + 1
 		indices = append(indices, length)
 	}
 
+	// Sort a copy so the option comments remain in the order they were added.
+	ocs = append([]optionComment(nil), ocs...)
+	for i := range ocs {
+		ocs[i].index += e.commandPath() // relative to the command path
+	}
 	sort.Sort(optionCommentSlice(ocs))
 
+	if e.trimComments {
+		for i := range ocs {
+			ocs[i].comment = strings.TrimRightFunc(ocs[i].comment, unicode.IsSpace)
+		}
+	}
+
+	if e.commentsAscending {
+		ascending := make([]optionComment, len(ocs))
+		for i, oc := range ocs {
+			ascending[len(ocs)-1-i] = oc
+		}
+		ocs = ascending
+	}
+
+	if e.noCarets {
+		for _, oc := range ocs {
+			var line string
+			switch {
+			case oc.all:
+				line = lines[0]
+			case oc.gap && oc.index >= 0 && oc.index < optCount:
+				line = "after " + opts[oc.index]
+			case oc.index >= 0 && oc.index < optCount:
+				line = opts[oc.index]
+			}
+			if line != "" && oc.comment != "" {
+				line += ": "
+			}
+			lines = append(lines, wrapComment(line, oc.comment, e.maxWidth)...)
+		}
+		return lines
+	}
+
+	spans := make([]span, 0, len(ocs))
+
 	for _, oc := range ocs {
-		if oc.index < 0 || oc.index >= optCount {
-			prefix := strings.Repeat(" ", length)
-			lines = append(lines, prefix+"^ "+oc.comment)
+		var col, width int
+
+		switch {
+		case oc.all:
+			col, width = 0, length-1
+		case oc.index < 0 || oc.index >= optCount:
+			col, width = length, 1
+		case oc.gap:
+			col, width = indices[oc.index+1]-1, 1
+		default:
+			col = indices[oc.index]
+			width = indices[oc.index+1] - col - 1
+			if oc.partial {
+				opt := e.options[oc.index]
+				start, length := partialSpan(opt, 0, oc.offset, oc.length)
+				if opts[oc.index] != opt {
+					start, length = quotedSpan(opt, oc.offset, oc.length)
+				}
+				col, width = col+start, length
+			}
+		}
+
+		if oc.wide {
+			if w := displayWidth(oc.comment); w > width {
+				width = w
+			}
+		}
+
+		if width < 1 {
+			width = 1 // point at the column where an empty option would be
+		}
+
+		spans = append(spans, span{col: col, width: width, comment: oc.comment})
+	}
+
+	if e.commentLegend {
+		return append(lines, legendLines(spans, e.maxWidth)...)
+	}
+
+	connector := " "
+	if e.isCommentConnectorSet {
+		connector = e.commentConnector
+	}
+
+	for _, sp := range spans {
+		if CaretFormatter != nil {
+			lines = append(lines, CaretFormatter(sp.col, sp.width, sp.comment))
 			continue
 		}
+		line := caretUnderline(lines[0], sp.col, sp.width)
+		if sp.comment != "" {
+			line += connector
+		}
+		lines = append(lines, wrapComment(line, sp.comment, e.maxWidth)...)
+	}
+
+	return lines
+}
+
+// CaretLine returns a line to be displayed beneath text that underlines width
+// columns of text starting at column col with a caret followed by tildes, and
+// then displays comment. The line is indented with spaces, except that a tab
+// in text before col is repeated so the caret stays aligned when text is
+// indented with tabs. ANSI SGR escape sequences in text do not occupy
+// columns. A col less than 0 is treated as 0, and a width less than 1 is
+// treated as 1.
+//
+//	fmt.Println(line)
+//	fmt.Println(goerr.CaretLine(line, 8, 5, "unknown key"))
+func CaretLine(text string, col, width int, comment string) string {
+	line := caretUnderline(text, col, max(width, 1))
+	if comment != "" {
+		line += " " + comment
+	}
+	return line
+}
+
+// caretUnderline returns a caret followed by width-1 tildes, indented to col
+// columns of text. A negative col is treated as 0.
+func caretUnderline(text string, col, width int) string {
+	col = max(col, 0)
+	var b strings.Builder
+	for _, r := range StripANSI(text) {
+		if col <= 0 {
+			break
+		}
+		if r == '\t' {
+			b.WriteByte('\t')
+			col--
+		} else {
+			w := min(runeWidth(r), col)
+			b.WriteString(strings.Repeat(" ", w))
+			col -= w
+		}
+	}
+	b.WriteString(strings.Repeat(" ", col))
+	b.WriteByte('^')
+	b.WriteString(strings.Repeat("~", width-1))
+	return b.String()
+}
+
+// span is an option comment resolved to the columns of the options line that
+// it refers to.
+type span struct {
+	comment string
+	col     int
+	width   int
+}
+
+// legendLines returns a line of numbered markers, each placed under the first
+// column of a span, followed by a line for each comment prefixed by its
+// marker. Spans are numbered from left to right, and markers that would
+// otherwise overlap are moved to the right. Comments are wrapped to maxWidth.
+func legendLines(spans []span, maxWidth int) []string {
+	sorted := append([]span(nil), spans...)
+	sort.SliceStable(sorted, func(i, j int) bool { return sorted[i].col < sorted[j].col })
+
+	var markers strings.Builder
+	legend := make([]string, 0, 1+len(sorted))
+	legend = append(legend, "") // placeholder for the markers line
+
+	for i, sp := range sorted {
+		marker := "(" + strconv.Itoa(i+1) + ")"
+		if pad := sp.col - markers.Len(); pad > 0 {
+			markers.WriteString(strings.Repeat(" ", pad))
+		} else if i > 0 {
+			markers.WriteString(" ")
+		}
+		markers.WriteString(marker)
+
+		line := marker
+		if sp.comment != "" {
+			line += " "
+		}
+		legend = append(legend, wrapComment(line, sp.comment, maxWidth)...)
+	}
+
+	legend[0] = markers.String()
+	return legend
+}
+
+// trimLines returns a copy of lines with trailing whitespace removed from each
+// line.
+func trimLines(lines []string) []string {
+	if len(lines) == 0 {
+		return lines
+	}
+	trimmed := make([]string, len(lines))
+	for i, line := range lines {
+		trimmed[i] = strings.TrimRightFunc(line, unicode.IsSpace)
+	}
+	return trimmed
+}
+
+// truncateLines returns a copy of lines with each line that is wider than cols
+// columns truncated by truncateLine.
+func truncateLines(lines []string, cols int) []string {
+	if len(lines) == 0 {
+		return lines
+	}
+	truncated := make([]string, len(lines))
+	for i, line := range lines {
+		truncated[i] = truncateLine(line, cols)
+	}
+	return truncated
+}
+
+// truncateLine returns line when it is at most cols columns wide, and
+// otherwise returns as much of line as fits in cols columns followed by an
+// ellipsis. ANSI SGR escape sequences do not count towards the width, and
+// those after the point of truncation are kept so that, for instance, a
+// sequence that resets the color still does so.
+func truncateLine(line string, cols int) string {
+	if displayWidth(line) <= cols {
+		return line
+	}
+
+	var b strings.Builder
+	var width int
+	var truncated bool
+	for len(line) > 0 {
+		if line[0] == '\x1b' {
+			if loc := sgrSequence.FindStringIndex(line); loc != nil && loc[0] == 0 {
+				b.WriteString(line[:loc[1]])
+				line = line[loc[1]:]
+				continue
+			}
+		}
+		r, size := utf8.DecodeRuneInString(line)
+		switch w := runeWidth(r); {
+		case truncated:
+		case width+w <= cols-1:
+			b.WriteString(line[:size])
+			width += w
+		default:
+			if cols > 0 {
+				b.WriteString("…")
+			}
+			truncated = true
+		}
+		line = line[size:]
+	}
+	return b.String()
+}
+
+// wrapComment returns prefix followed by comment, with comment wrapped at
+// spaces so each line is at most width columns wide, when width is greater
+// than 0. Continuation lines are indented to align with the start of comment
+// on the first line. A word too long to fit is placed on a line of its own.
+func wrapComment(prefix, comment string, width int) []string {
+	indent := displayWidth(prefix)
+	if width <= 0 || indent+displayWidth(comment) <= width {
+		return []string{prefix + comment}
+	}
+
+	var lines []string
+	line, lineWidth := prefix, indent
+	for _, word := range strings.Fields(comment) {
+		w := displayWidth(word)
+		if lineWidth > indent && lineWidth+1+w > width {
+			lines = append(lines, line)
+			line, lineWidth = strings.Repeat(" ", indent), indent
+		}
+		if lineWidth > indent {
+			line += " "
+			lineWidth++
+		}
+		line += word
+		lineWidth += w
+	}
+	return append(lines, line)
+}
+
+// clampSpan returns offset and length clamped so that a span of length runes
+// beginning offset runes into a string of n runes remains within the string,
+// and is at least one rune long.
+func clampSpan(n, offset, length int) (int, int) {
+	if offset > n-1 {
+		offset = n - 1
+	}
+	if offset < 0 {
+		offset = 0
+	}
+	if length > n-offset {
+		length = n - offset
+	}
+	if length < 1 {
+		length = 1
+	}
+	return offset, length
+}
+
+// partialSpan returns the column and width of the span of length runes that
+// begins offset runes into opt, which itself begins at column col. Both
+// offset and length are clamped so the span remains within opt.
+func partialSpan(opt string, col, offset, length int) (int, int) {
+	runes := []rune(StripANSI(opt))
+	offset, length = clampSpan(len(runes), offset, length)
+	end := min(offset+length, len(runes))
+	return col + runesWidth(runes[:offset]), max(runesWidth(runes[offset:end]), 1)
+}
 
-		prefix := strings.Repeat(" ", indices[oc.index]) + "^"
+// quotedSpan returns the column and width, within shellQuote(opt), of the span
+// of length runes that begins offset runes into opt, so the span underlines
+// the same runes after they are quoted. A single quote, which shellQuote
+// escapes with a backslash, is represented by the escaped quote.
+func quotedSpan(opt string, offset, length int) (int, int) {
+	runes := []rune(StripANSI(opt))
+	offset, length = clampSpan(len(runes), offset, length)
 
-		if oc.index == optCount-1 {
-			prefix += strings.Repeat("~", (length-indices[oc.index])-2)
+	var columns, widths []int
+	col := 1 // skip opening quote
+	for _, r := range runes {
+		if r == '\'' {
+			columns = append(columns, col+2)
+			widths = append(widths, 1)
+			col += 4
 		} else {
-			prefix += strings.Repeat("~", (indices[oc.index+1] - indices[oc.index] - 2))
+			w := runeWidth(r)
+			columns = append(columns, col)
+			widths = append(widths, w)
+			col += w
 		}
+	}
+	if offset >= len(columns) {
+		return col, 1 // point at the closing quote
+	}
+	last := min(offset+length, len(columns)) - 1
+	return columns[offset], columns[last] + widths[last] - columns[offset]
+}
 
-		lines = append(lines, prefix+" "+oc.comment)
+// shellQuote returns s quoted for a POSIX shell when s is empty or contains
+// whitespace or characters special to the shell, and otherwise returns s.
+func shellQuote(s string) string {
+	if s != "" && !strings.ContainsAny(s, " \t\n\"'\\$`;&|<>()*?[]{}#~!") {
+		return s
 	}
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
 
-	return lines
+// sgrSequence matches ANSI Select Graphic Rendition escape sequences, which
+// change the color and style of text without occupying columns.
+var sgrSequence = regexp.MustCompile("\x1b\\[[0-9;]*m")
+
+// displayWidth returns the number of columns s occupies when displayed,
+// ignoring any ANSI SGR escape sequences. East Asian wide and fullwidth
+// runes occupy two columns, and all other runes occupy one.
+func displayWidth(s string) int {
+	return runesWidth([]rune(StripANSI(s)))
+}
+
+// runesWidth returns the number of columns runes occupy when displayed.
+func runesWidth(runes []rune) int {
+	var width int
+	for _, r := range runes {
+		width += runeWidth(r)
+	}
+	return width
+}
+
+// wideRanges are the inclusive ranges of East Asian wide and fullwidth runes,
+// such as CJK ideographs, Hangul syllables, and fullwidth forms, which occupy
+// two columns when displayed.
+var wideRanges = [][2]rune{
+	{0x1100, 0x115F},
+	{0x2E80, 0x303E},
+	{0x3041, 0x33FF},
+	{0x3400, 0x4DBF},
+	{0x4E00, 0x9FFF},
+	{0xA000, 0xA4CF},
+	{0xAC00, 0xD7A3},
+	{0xF900, 0xFAFF},
+	{0xFE30, 0xFE4F},
+	{0xFF00, 0xFF60},
+	{0xFFE0, 0xFFE6},
+	{0x20000, 0x3FFFD},
+}
+
+// runeWidth returns 2 when r is an East Asian wide or fullwidth rune, and
+// otherwise returns 1.
+func runeWidth(r rune) int {
+	if r < wideRanges[0][0] {
+		return 1
+	}
+	for _, wr := range wideRanges {
+		if r >= wr[0] && r <= wr[1] {
+			return 2
+		}
+	}
+	return 1
+}
+
+// StripANSI returns s without any ANSI SGR escape sequences, such as those
+// that color text, which is useful when writing colored error lines to a
+// destination that is not a terminal.
+func StripANSI(s string) string {
+	if strings.IndexByte(s, '\x1b') < 0 {
+		return s
+	}
+	return sgrSequence.ReplaceAllString(s, "")
 }
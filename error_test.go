@@ -1,9 +1,12 @@
 package goerr_test
 
 import (
+	"errors"
 	"fmt"
 	"strconv"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/karrick/goerr"
 )
@@ -18,7 +21,7 @@ func ExampleExitCode() {
 	// 13
 }
 
-func ExampleMultiLine() {
+func Example_multiLine() {
 	// Lines are printed in the order in which they were added.
 	err := goerr.New("cannot do thing").
 		WithLineAfterOptions("line 2").
@@ -38,7 +41,7 @@ func ExampleMultiLine() {
 	// line 5
 }
 
-func ExampleWithOptionComments() {
+func Example_withOptionComments() {
 	args := []string{"zero", "one", "--two", "three"}
 
 	// NOTE: Lines are added in the order given for each section:
@@ -86,7 +89,7 @@ func parseIntegerOption(s string) (int, error) {
 		WithExitCode(2)
 }
 
-func ExampleMaybeWrapNonNilError() {
+func Example_maybeWrapNonNilError() {
 	value, err := parseIntegerOption("123")
 
 	// NOTE: Because Wrap and Wrapf return nil when it is given a nil error to
@@ -102,7 +105,7 @@ func ExampleMaybeWrapNonNilError() {
 	// 0
 }
 
-func ExampleMaybeWrapNilError() {
+func Example_maybeWrapNilError() {
 	value, err := parseIntegerOption("123abc")
 
 	fmt.Println(value)
@@ -114,6 +117,137 @@ func ExampleMaybeWrapNilError() {
 	// 2
 }
 
+func wrapInHelper(err error) *goerr.Error {
+	return goerr.Wrapf(err, "cannot do helper thing")
+}
+
+func TestFromRecover(t *testing.T) {
+	recovered := func(fn func()) (err *goerr.Error) {
+		defer func() {
+			err = goerr.FromRecover(recover(), 70)
+		}()
+		fn()
+		return nil
+	}
+
+	t.Run("nil", func(t *testing.T) {
+		if got, want := recovered(func() {}), (*goerr.Error)(nil); got != want {
+			t.Errorf("GOT: %v; WANT: %v", got, want)
+		}
+	})
+
+	t.Run("string", func(t *testing.T) {
+		ee := recovered(func() { panic("boom") })
+
+		if got, want := ee.Error(), "panic: boom"; got != want {
+			t.Errorf("GOT: %q; WANT: %q", got, want)
+		}
+		if got, want := ee.ExitCode(), 70; got != want {
+			t.Errorf("GOT: %v; WANT: %v", got, want)
+		}
+	})
+
+	t.Run("error", func(t *testing.T) {
+		cause := errors.New("boom")
+		ee := recovered(func() { panic(cause) })
+
+		if got, want := ee.Unwrap(), cause; got != want {
+			t.Errorf("GOT: %v; WANT: %v", got, want)
+		}
+		if got, want := ee.ExitCode(), 70; got != want {
+			t.Errorf("GOT: %v; WANT: %v", got, want)
+		}
+	})
+}
+
+func TestStripANSI(t *testing.T) {
+	if got, want := goerr.StripANSI("\x1b[1;31mred\x1b[0m and plain"), "red and plain"; got != want {
+		t.Errorf("GOT: %q; WANT: %q", got, want)
+	}
+	if got, want := goerr.StripANSI("plain"), "plain"; got != want {
+		t.Errorf("GOT: %q; WANT: %q", got, want)
+	}
+}
+
+func loadSettings(err error) *goerr.Error {
+	return goerr.WrapAuto(err)
+}
+
+func TestWrapAuto(t *testing.T) {
+	t.Run("err nil", func(t *testing.T) {
+		if got, want := loadSettings(nil), (*goerr.Error)(nil); got != want {
+			t.Errorf("GOT: %v; WANT: %v", got, want)
+		}
+	})
+
+	t.Run("err not nil", func(t *testing.T) {
+		ee := loadSettings(errors.New("file not found"))
+
+		if got, want := ee.Error(), "loadSettings failed: file not found"; got != want {
+			t.Errorf("GOT: %q; WANT: %q", got, want)
+		}
+	})
+}
+
+func TestWrapFunc(t *testing.T) {
+	configure := func(e *goerr.Error) {
+		e.WithExitCode(13).WithTemporary(true)
+	}
+
+	t.Run("err nil", func(t *testing.T) {
+		if got := goerr.WrapFunc(nil, configure); got != nil {
+			t.Errorf("GOT: %v; WANT: nil", got)
+		}
+	})
+
+	t.Run("err not nil", func(t *testing.T) {
+		cause := errors.New("some error")
+		err := goerr.WrapFunc(cause, configure)
+
+		if got, want := errors.Unwrap(err), cause; got != want {
+			t.Errorf("GOT: %v; WANT: %v", got, want)
+		}
+		if got, want := goerr.ExitCode(err), 13; got != want {
+			t.Errorf("GOT: %v; WANT: %v", got, want)
+		}
+		if got, want := goerr.Temporary(err), true; got != want {
+			t.Errorf("GOT: %v; WANT: %v", got, want)
+		}
+	})
+}
+
+func TestCaretLine(t *testing.T) {
+	t.Run("spaces", func(t *testing.T) {
+		if got, want := goerr.CaretLine("timeout = 3x", 10, 2, "invalid duration"), "          ^~ invalid duration"; got != want {
+			t.Errorf("GOT: %q; WANT: %q", got, want)
+		}
+	})
+
+	t.Run("tabs", func(t *testing.T) {
+		if got, want := goerr.CaretLine("\tport: abc", 7, 3, ""), "\t      ^~~"; got != want {
+			t.Errorf("GOT: %q; WANT: %q", got, want)
+		}
+	})
+
+	t.Run("past end of text", func(t *testing.T) {
+		if got, want := goerr.CaretLine("key", 4, 0, "missing value"), "    ^ missing value"; got != want {
+			t.Errorf("GOT: %q; WANT: %q", got, want)
+		}
+	})
+
+	t.Run("wide runes", func(t *testing.T) {
+		if got, want := goerr.CaretLine("键 = 值", 6, 2, "bad value"), "      ^~ bad value"; got != want {
+			t.Errorf("GOT: %q; WANT: %q", got, want)
+		}
+	})
+
+	t.Run("negative column", func(t *testing.T) {
+		if got, want := goerr.CaretLine("key", -3, 2, "bad key"), "^~ bad key"; got != want {
+			t.Errorf("GOT: %q; WANT: %q", got, want)
+		}
+	})
+}
+
 func TestError(t *testing.T) {
 	t.Run("init", func(t *testing.T) {
 		t.Run("MaybeWrap", func(t *testing.T) {
@@ -196,6 +330,214 @@ func TestError(t *testing.T) {
 			})
 		})
 
+		t.Run("Coerce", func(t *testing.T) {
+			t.Run("sans error", func(t *testing.T) {
+				if got, want := goerr.Coerce(nil), (*goerr.Error)(nil); got != want {
+					t.Errorf("GOT: %v; WANT: %v", got, want)
+				}
+			})
+
+			t.Run("with *Error", func(t *testing.T) {
+				err := goerr.New("some error message")
+
+				if got, want := goerr.Coerce(err), err; got != want {
+					t.Errorf("GOT: %p; WANT: %p", got, want)
+				}
+			})
+
+			t.Run("with error", func(t *testing.T) {
+				err := fmt.Errorf("foo: %v", "bar")
+
+				ee := goerr.Coerce(err)
+
+				if got, want := ee.Unwrap(), err; got != want {
+					t.Errorf("GOT: %v; WANT: %v", got, want)
+				}
+				if got, want := ee.Error(), "foo: bar"; got != want {
+					t.Errorf("GOT: %q; WANT: %q", got, want)
+				}
+			})
+		})
+
+		t.Run("FromExitError", func(t *testing.T) {
+			t.Run("sans error", func(t *testing.T) {
+				if got, want := goerr.FromExitError(nil), (*goerr.Error)(nil); got != want {
+					t.Errorf("GOT: %v; WANT: %v", got, want)
+				}
+			})
+
+			t.Run("with exit coder", func(t *testing.T) {
+				err := goerr.FromExitError(&dummyUnwrapper{err: &dummyExitCoder{code: 42}})
+
+				if got, want := goerr.ExitCode(err), 42; got != want {
+					t.Errorf("GOT: %v; WANT: %v", got, want)
+				}
+				if got, want := err.Error(), "unwraps err: returns exit code: 42"; got != want {
+					t.Errorf("GOT: %q; WANT: %q", got, want)
+				}
+			})
+
+			t.Run("sans exit coder", func(t *testing.T) {
+				err := goerr.FromExitError(fmt.Errorf("foo: %v", "bar"))
+
+				if got, want := goerr.ExitCode(err), 0; got != want {
+					t.Errorf("GOT: %v; WANT: %v", got, want)
+				}
+				if got, want := err.Error(), "foo: bar"; got != want {
+					t.Errorf("GOT: %q; WANT: %q", got, want)
+				}
+			})
+		})
+
+		t.Run("Merge", func(t *testing.T) {
+			t.Run("both nil", func(t *testing.T) {
+				if got, want := goerr.Merge(nil, nil), (*goerr.Error)(nil); got != want {
+					t.Errorf("GOT: %v; WANT: %v", got, want)
+				}
+			})
+
+			t.Run("secondary nil", func(t *testing.T) {
+				err := goerr.Merge(&dummyExitCoder{code: 42}, nil)
+
+				if got, want := err.Error(), "returns exit code: 42"; got != want {
+					t.Errorf("GOT: %q; WANT: %q", got, want)
+				}
+				if got, want := goerr.ExitCode(err), 42; got != want {
+					t.Errorf("GOT: %v; WANT: %v", got, want)
+				}
+			})
+
+			t.Run("primary exit coder", func(t *testing.T) {
+				err := goerr.Merge(&dummyExitCoder{code: 42}, errors.New("cannot configure"))
+
+				if got, want := err.Error(), "returns exit code: 42\ncannot configure"; got != want {
+					t.Errorf("GOT: %q; WANT: %q", got, want)
+				}
+				if got, want := goerr.ExitCode(err), 42; got != want {
+					t.Errorf("GOT: %v; WANT: %v", got, want)
+				}
+			})
+
+			t.Run("secondary exit coder", func(t *testing.T) {
+				err := goerr.Merge(errors.New("cannot configure"), &dummyExitCoder{code: 42})
+
+				if got, want := err.Error(), "cannot configure\nreturns exit code: 42"; got != want {
+					t.Errorf("GOT: %q; WANT: %q", got, want)
+				}
+				if got, want := goerr.ExitCode(err), 42; got != want {
+					t.Errorf("GOT: %v; WANT: %v", got, want)
+				}
+			})
+
+			t.Run("primary wrapped exit coder", func(t *testing.T) {
+				err := goerr.Merge(goerr.Wrapf(&dummyExitCoder{code: 42}, "x"), &dummyExitCoder{code: 13})
+
+				if got, want := goerr.ExitCode(err), 42; got != want {
+					t.Errorf("GOT: %v; WANT: %v", got, want)
+				}
+			})
+
+			t.Run("secondary wrapped temporary", func(t *testing.T) {
+				err := goerr.Merge(errors.New("cannot configure"), goerr.Wrapf(goerr.New("busy").WithTemporary(true), "x"))
+
+				if got, want := goerr.Temporary(err), true; got != want {
+					t.Errorf("GOT: %v; WANT: %v", got, want)
+				}
+			})
+
+			t.Run("primary wins", func(t *testing.T) {
+				primary := goerr.New("primary").WithExitCode(13)
+				secondary := goerr.New("secondary").WithExitCode(42).WithTemporary(true)
+
+				err := goerr.Merge(primary, secondary)
+
+				if got, want := goerr.ExitCode(err), 13; got != want {
+					t.Errorf("GOT: %v; WANT: %v", got, want)
+				}
+				if got, want := goerr.Temporary(err), true; got != want {
+					t.Errorf("GOT: %v; WANT: %v", got, want)
+				}
+				if got, want := errors.Unwrap(err), error(primary); got != want {
+					t.Errorf("GOT: %v; WANT: %v", got, want)
+				}
+			})
+		})
+
+		t.Run("Tee", func(t *testing.T) {
+			var logged []error
+			logfn := func(err error) { logged = append(logged, err) }
+
+			t.Run("sans error", func(t *testing.T) {
+				logged = nil
+
+				if got, want := goerr.Tee(nil, logfn), error(nil); got != want {
+					t.Errorf("GOT: %v; WANT: %v", got, want)
+				}
+				if got, want := len(logged), 0; got != want {
+					t.Errorf("GOT: %v; WANT: %v", got, want)
+				}
+			})
+
+			t.Run("wrapping nil error", func(t *testing.T) {
+				logged = nil
+				var err error
+
+				if got := goerr.Tee(goerr.Wrapf(err, "cannot configure"), logfn); got != nil {
+					t.Errorf("GOT: %#v; WANT: nil", got)
+				}
+				if got, want := len(logged), 0; got != want {
+					t.Errorf("GOT: %v; WANT: %v", got, want)
+				}
+			})
+
+			t.Run("with error", func(t *testing.T) {
+				logged = nil
+				err := goerr.New("some error message")
+
+				if got, want := goerr.Tee(err, logfn), error(err); got != want {
+					t.Errorf("GOT: %v; WANT: %v", got, want)
+				}
+				if got, want := len(logged), 1; got != want {
+					t.Fatalf("GOT: %v; WANT: %v", got, want)
+				}
+				if got, want := logged[0], error(err); got != want {
+					t.Errorf("GOT: %v; WANT: %v", got, want)
+				}
+			})
+		})
+
+		t.Run("WrapSentinel", func(t *testing.T) {
+			sentinel := errors.New("sentinel")
+
+			t.Run("sans error", func(t *testing.T) {
+				if got, want := goerr.WrapSentinel(nil, sentinel, "context"), (*goerr.Error)(nil); got != want {
+					t.Errorf("GOT: %v; WANT: %v", got, want)
+				}
+			})
+
+			t.Run("with error", func(t *testing.T) {
+				cause := fmt.Errorf("foo: %v", "bar")
+
+				err := goerr.WrapSentinel(cause, sentinel, "cannot %s", "configure")
+
+				if got, want := err.Error(), "cannot configure: foo: bar"; got != want {
+					t.Errorf("GOT: %q; WANT: %q", got, want)
+				}
+				if got, want := errors.Is(err, sentinel), true; got != want {
+					t.Errorf("GOT: %v; WANT: %v", got, want)
+				}
+				if got, want := errors.Is(err, cause), true; got != want {
+					t.Errorf("GOT: %v; WANT: %v", got, want)
+				}
+				if got, want := errors.Unwrap(err), cause; got != want {
+					t.Errorf("GOT: %v; WANT: %v", got, want)
+				}
+				if got, want := errors.Is(err, errors.New("sentinel")), false; got != want {
+					t.Errorf("GOT: %v; WANT: %v", got, want)
+				}
+			})
+		})
+
 		t.Run("New", func(t *testing.T) {
 			ee := goerr.New("cannot parse int: %q", "123abc")
 
@@ -250,6 +592,17 @@ func TestError(t *testing.T) {
 	})
 
 	t.Run("Error", func(t *testing.T) {
+		t.Run("nil Error", func(t *testing.T) {
+			var ee *goerr.Error
+
+			if got, want := ee.Error(), "<nil>"; got != want {
+				t.Errorf("GOT: %q; WANT: %q", got, want)
+			}
+			if got := ee.ErrorLines(); got != nil {
+				t.Errorf("GOT: %q; WANT: nil", got)
+			}
+		})
+
 		t.Run("sans error", func(t *testing.T) {
 			t.Run("sans message", func(t *testing.T) {
 				var ee goerr.Error
@@ -286,6 +639,31 @@ func TestError(t *testing.T) {
 			})
 		})
 
+		t.Run("fast path matches lines", func(t *testing.T) {
+			for _, ee := range []*goerr.Error{
+				new(goerr.Error),
+				goerr.New("foo: %v", "bar"),
+				goerr.Wrap(fmt.Errorf("foo: %v", "bar")),
+				goerr.Wrapf(fmt.Errorf("foo: %v", "bar"), "cannot configure"),
+				goerr.New("some error message").WithOptionComment(1, "sans options"),
+			} {
+				if got, want := ee.Error(), strings.Join(ee.ErrorLines(), "\n"); got != want {
+					t.Errorf("GOT: %q; WANT: %q", got, want)
+				}
+			}
+		})
+
+		t.Run("with cause separator", func(t *testing.T) {
+			defer func(s string) { goerr.CauseSeparator = s }(goerr.CauseSeparator)
+			goerr.CauseSeparator = " -> "
+
+			ee := goerr.Wrapf(fmt.Errorf("foo: %v", "bar"), "cannot configure")
+
+			if got, want := ee.Error(), "cannot configure -> foo: bar"; got != want {
+				t.Errorf("GOT: %q; WANT: %q", got, want)
+			}
+		})
+
 		t.Run("with options sans comments", func(t *testing.T) {
 			err := goerr.New("some error message").
 				WithOptions([]string{"zero", "one", "--two", "three"})
@@ -343,41 +721,1949 @@ func TestError(t *testing.T) {
 		})
 	})
 
-	t.Run("ExitCode", func(t *testing.T) {
-		t.Run("sans exit code", func(t *testing.T) {
-			var ee goerr.Error
+	t.Run("WrapSite", func(t *testing.T) {
+		t.Run("disabled", func(t *testing.T) {
+			err := wrapInHelper(fmt.Errorf("foo: %v", "bar"))
 
-			if got, want := ee.ExitCode(), 0; got != want {
+			if got, want := err.WrapSite(), ""; got != want {
+				t.Errorf("GOT: %q; WANT: %q", got, want)
+			}
+		})
+
+		t.Run("enabled", func(t *testing.T) {
+			defer func(b bool) { goerr.CaptureWrapSite = b }(goerr.CaptureWrapSite)
+			goerr.CaptureWrapSite = true
+
+			err := wrapInHelper(fmt.Errorf("foo: %v", "bar"))
+
+			if got, want := err.WrapSite(), "github.com/karrick/goerr_test.wrapInHelper"; got != want {
+				t.Errorf("GOT: %q; WANT: %q", got, want)
+			}
+		})
+	})
+
+	t.Run("AccumulateExitCode", func(t *testing.T) {
+		err := goerr.New("some error message")
+
+		for _, code := range []int{2, 13, 5} {
+			err.AccumulateExitCode(code)
+		}
+
+		if got, want := err.ExitCode(), 13; got != want {
+			t.Errorf("GOT: %v; WANT: %v", got, want)
+		}
+
+		t.Run("nil receiver", func(t *testing.T) {
+			var ee *goerr.Error
+
+			if got, want := ee.AccumulateExitCode(13), (*goerr.Error)(nil); got != want {
 				t.Errorf("GOT: %v; WANT: %v", got, want)
 			}
 		})
+	})
 
-		t.Run("with exit code", func(t *testing.T) {
-			ee := new(goerr.Error)
+	t.Run("Annotations", func(t *testing.T) {
+		ee := goerr.New("some error message").
+			WithOptions([]string{"zero", "one", "--two", "three"}).
+			WithOptionComment(2, "for this option").
+			WithOptionCommentAll("for all options").
+			WithOptionComment(1, "for this sub-command")
+
+		_ = ee.ErrorLines() // rendering does not reorder the annotations
+
+		want := []goerr.OptionAnnotation{
+			{Index: 2, Comment: "for this option"},
+			{Index: 1, Comment: "for this sub-command"},
+		}
+		got := ee.Annotations()
+		if len(got) != len(want) {
+			t.Fatalf("GOT: %v; WANT: %v", got, want)
+		}
+		for i := range want {
+			if got[i] != want[i] {
+				t.Errorf("GOT: %v; WANT: %v", got[i], want[i])
+			}
+		}
+		if got, want := got[0].String(), "2: for this option"; got != want {
+			t.Errorf("GOT: %q; WANT: %q", got, want)
+		}
+	})
 
-			ee = ee.WithExitCode(13)
+	t.Run("Append", func(t *testing.T) {
+		t.Run("nil other", func(t *testing.T) {
+			err := goerr.New("some error message").Append(nil)
 
-			if got, want := ee.ExitCode(), 13; got != want {
+			if got, want := err.Error(), "some error message"; got != want {
+				t.Errorf("GOT: %q; WANT: %q", got, want)
+			}
+		})
+
+		t.Run("decorated other", func(t *testing.T) {
+			other := goerr.New("other error message").
+				WithLineBeforeMessage("other before").
+				WithLineBetweenMessageAndOption("other between").
+				WithOptions([]string{"--three", "four"}).
+				WithOptionComment(0, "for other option").
+				WithLineAfterOptions("other after")
+
+			err := goerr.New("some error message").
+				WithLineBeforeMessage("before").
+				WithOptions([]string{"zero", "one", "--two"}).
+				WithOptionComment(2, "for this option").
+				WithLineAfterOptions("after").
+				Append(other)
+
+			want := []string{
+				"before",
+				"other before",
+				"some error message",
+				"other between",
+				"zero one --two --three four",
+				"               ^~~~~~~ for other option",
+				"         ^~~~~ for this option",
+				"after",
+				"other after",
+			}
+
+			lines := err.ErrorLines()
+			if got, want := len(lines), len(want); got != want {
+				t.Fatalf("GOT: %q; WANT: %v lines", lines, want)
+			}
+			for i := range want {
+				if got, want := lines[i], want[i]; got != want {
+					t.Errorf("GOT: %q; WANT: %q", got, want)
+				}
+			}
+
+			// The other error is unchanged.
+			if got, want := len(other.ErrorLines()), 6; got != want {
 				t.Errorf("GOT: %v; WANT: %v", got, want)
 			}
 		})
 	})
 
-	t.Run("Unwrap", func(t *testing.T) {
-		t.Run("sans wrapped error", func(t *testing.T) {
-			var ee goerr.Error
+	t.Run("Bare", func(t *testing.T) {
+		t.Run("nil", func(t *testing.T) {
+			var ee *goerr.Error
 
-			if got, want := ee.Unwrap(), error(nil); got != want {
+			if got, want := ee.Bare(), (*goerr.Error)(nil); got != want {
 				t.Errorf("GOT: %v; WANT: %v", got, want)
 			}
 		})
 
-		t.Run("with wrapped error", func(t *testing.T) {
-			ee := goerr.Wrap(fmt.Errorf("foo: %v", "bar"))
+		t.Run("decorated", func(t *testing.T) {
+			err := goerr.Wrapf(fmt.Errorf("foo: %v", "bar"), "cannot configure").
+				WithLineBeforeMessage("before").
+				WithLineBetweenMessageAndOption("between").
+				WithOptions([]string{"zero", "one"}).
+				WithOptionComment(1, "for this option").
+				WithLineAfterOptions("after").
+				WithExitCode(13).
+				WithTemporary(true)
 
-			if got, want := ee.Unwrap().Error(), "foo: bar"; got != want {
+			bare := err.Bare()
+
+			if got, want := bare.Error(), "cannot configure: foo: bar"; got != want {
+				t.Errorf("GOT: %q; WANT: %q", got, want)
+			}
+			if got, want := bare.ExitCode(), 13; got != want {
+				t.Errorf("GOT: %v; WANT: %v", got, want)
+			}
+			if got, want := bare.Temporary(), true; got != want {
+				t.Errorf("GOT: %v; WANT: %v", got, want)
+			}
+			if got, want := len(err.ErrorLines()), 6; got != want {
+				t.Errorf("GOT: %v; WANT: %v", got, want)
+			}
+		})
+	})
+
+	t.Run("Fingerprint", func(t *testing.T) {
+		build := func(code int) *goerr.Error {
+			return goerr.Wrapf(fmt.Errorf("open: %w", errors.New("permission denied")), "cannot configure").
+				WithExitCode(code)
+		}
+
+		a := build(13).WithLineAfterOptions("decorations do not count")
+		b := build(13)
+		c := build(14)
+
+		if got, want := len(a.Fingerprint()), 64; got != want {
+			t.Errorf("GOT: %v; WANT: %v", got, want)
+		}
+		if a.Fingerprint() != b.Fingerprint() {
+			t.Errorf("GOT: %q and %q; WANT: equal fingerprints", a.Fingerprint(), b.Fingerprint())
+		}
+		if a.Fingerprint() == c.Fingerprint() {
+			t.Errorf("GOT: %q for both; WANT: different fingerprints", a.Fingerprint())
+		}
+
+		t.Run("exit code func", func(t *testing.T) {
+			var calls int
+			d := build(13).WithExitCodeFunc(func() int {
+				calls++
+				return calls
+			})
+
+			if got, want := d.Fingerprint(), d.Fingerprint(); got != want {
+				t.Errorf("GOT: %q; WANT: %q", got, want)
+			}
+			if got, want := d.Fingerprint(), b.Fingerprint(); got != want {
+				t.Errorf("GOT: %q; WANT: %q", got, want)
+			}
+		})
+	})
+
+	t.Run("GoString", func(t *testing.T) {
+		err := goerr.New("some error message").
+			WithExitCode(13).
+			WithOptions([]string{"zero", "one"}).
+			WithOptionComment(1, "for this option")
+
+		got := fmt.Sprintf("%#v", err)
+
+		for _, want := range []string{
+			"goerr.Error{",
+			`msg:"some error message"`,
+			"exitCode:13",
+			"isExitCodeSet:true",
+			"temporary:false",
+			"isTemporarySet:false",
+			`options:[]string{"zero", "one"}`,
+			`comment:"for this option"`,
+			"index:1",
+		} {
+			if !strings.Contains(got, want) {
+				t.Errorf("GOT: %q; WANT: %q", got, want)
+			}
+		}
+	})
+
+	t.Run("HasAnnotations", func(t *testing.T) {
+		ee := goerr.New("some error message").WithOptions([]string{"zero", "one"})
+
+		if got, want := ee.HasAnnotations(), false; got != want {
+			t.Errorf("GOT: %v; WANT: %v", got, want)
+		}
+
+		ee.WithOptionComment(1, "for this option")
+
+		if got, want := ee.HasAnnotations(), true; got != want {
+			t.Errorf("GOT: %v; WANT: %v", got, want)
+		}
+
+		ee.WithCarets(false)
+
+		if got, want := ee.HasAnnotations(), false; got != want {
+			t.Errorf("GOT: %v; WANT: %v", got, want)
+		}
+	})
+
+	t.Run("HasDuplicateCommentIndices", func(t *testing.T) {
+		t.Run("clean", func(t *testing.T) {
+			err := goerr.New("some error message").
+				WithOptions([]string{"zero", "--one=two"}).
+				WithOptionComment(0, "for this sub-command").
+				WithOptionComment(1, "for this option").
+				WithOptionCommentAt(1, 6, 3, "invalid value").
+				WithOptionCommentAll("all")
+
+			if got, want := err.HasDuplicateCommentIndices(), false; got != want {
 				t.Errorf("GOT: %v; WANT: %v", got, want)
 			}
 		})
+
+		t.Run("duplicate", func(t *testing.T) {
+			defer func(b bool) { goerr.StrictOptionIndices = b }(goerr.StrictOptionIndices)
+
+			err := goerr.New("some error message").
+				WithOptions([]string{"zero", "one"}).
+				WithOptionComment(1, "for this option").
+				WithOptionComment(1, "conflicting comment")
+
+			if got, want := err.HasDuplicateCommentIndices(), true; got != want {
+				t.Errorf("GOT: %v; WANT: %v", got, want)
+			}
+
+			goerr.StrictOptionIndices = false
+
+			if got, want := err.Validate(), error(nil); got != want {
+				t.Errorf("GOT: %v; WANT: %v", got, want)
+			}
+
+			goerr.StrictOptionIndices = true
+
+			if got, want := fmt.Sprint(err.Validate()), "more than one option comment refers to the same option"; got != want {
+				t.Errorf("GOT: %q; WANT: %q", got, want)
+			}
+		})
+	})
+
+	t.Run("MaxLineWidth", func(t *testing.T) {
+		ee := goerr.New("bad flag").
+			WithOptions([]string{"ls", "--colour"}).
+			WithOptionComment(1, "unknown flag: did you mean --color?").
+			WithLineAfterOptions("see ls --help")
+
+		// The comment line is the widest: "   ^~~~~~~ unknown flag: ..."
+		if got, want := ee.MaxLineWidth(), 47; got != want {
+			t.Errorf("GOT: %v; WANT: %v", got, want)
+		}
+	})
+
+	t.Run("MaxLineWidth CJK", func(t *testing.T) {
+		ee := goerr.New("无法读取文件").
+			WithOptions([]string{"cat", "文件名.txt"}).
+			WithOptionComment(1, "not found")
+
+		want := []string{
+			"无法读取文件",
+			"cat 文件名.txt",
+			"    ^~~~~~~~~~ not found",
+		}
+		lines := ee.ErrorLines()
+		if got, want := len(lines), len(want); got != want {
+			t.Fatalf("GOT: %q; WANT: %v lines", lines, want)
+		}
+		for i := range want {
+			if got, want := lines[i], want[i]; got != want {
+				t.Errorf("GOT: %q; WANT: %q", got, want)
+			}
+		}
+
+		if got, want := ee.MaxLineWidth(), 24; got != want {
+			t.Errorf("GOT: %v; WANT: %v", got, want)
+		}
+	})
+
+	t.Run("NumberedLines", func(t *testing.T) {
+		lines := goerr.New("some error message").
+			WithLineBeforeMessage("before").
+			WithOptions([]string{"zero", "one", "--two"}).
+			WithOptionComment(1, "for this sub-command").
+			WithOptionComment(2, "for this option").
+			WithLinesAfterOptions([]string{"a", "b", "c", "d", "e", "f", "g"}).
+			NumberedLines()
+
+		want := []string{
+			" 1: before",
+			" 2: some error message",
+			" 3: zero one --two",
+			"             ^~~~~ for this option",
+			"         ^~~ for this sub-command",
+			" 4: a",
+			" 5: b",
+			" 6: c",
+			" 7: d",
+			" 8: e",
+			" 9: f",
+			"10: g",
+		}
+
+		if got, want := len(lines), len(want); got != want {
+			t.Fatalf("GOT: %q; WANT: %v lines", lines, want)
+		}
+		for i := range want {
+			if got, want := lines[i], want[i]; got != want {
+				t.Errorf("GOT: %q; WANT: %q", got, want)
+			}
+		}
+	})
+
+	t.Run("OptionAnnotationLines", func(t *testing.T) {
+		t.Run("sans options", func(t *testing.T) {
+			err := goerr.New("some error message")
+
+			if got, want := len(err.OptionAnnotationLines()), 0; got != want {
+				t.Errorf("GOT: %v; WANT: %v", got, want)
+			}
+		})
+
+		t.Run("with options", func(t *testing.T) {
+			err := goerr.New("some error message").
+				WithLineBeforeMessage("before").
+				WithLineBetweenMessageAndOption("between").
+				WithOptions([]string{"zero", "one", "--two"}).
+				WithOptionComment(1, "for this sub-command").
+				WithOptionComment(2, "for this option").
+				WithLineAfterOptions("after")
+
+			want := []string{
+				"zero one --two",
+				"         ^~~~~ for this option",
+				"     ^~~ for this sub-command",
+			}
+
+			lines := err.OptionAnnotationLines()
+			if got, want := len(lines), len(want); got != want {
+				t.Fatalf("GOT: %q; WANT: %v lines", lines, want)
+			}
+			for i := range want {
+				if got, want := lines[i], want[i]; got != want {
+					t.Errorf("GOT: %q; WANT: %q", got, want)
+				}
+			}
+		})
+	})
+
+	t.Run("OptionRuler", func(t *testing.T) {
+		ee := goerr.New("some error message").
+			WithOptions([]string{"zero", "one", "--two", "x"})
+
+		if got, want := ee.ErrorLines()[1], "zero one --two x"; got != want {
+			t.Fatalf("GOT: %q; WANT: %q", got, want)
+		}
+		if got, want := ee.OptionRuler(), "|----|---|-----||"; got != want {
+			t.Errorf("GOT: %q; WANT: %q", got, want)
+		}
+
+		t.Run("sans options", func(t *testing.T) {
+			if got, want := goerr.New("some error message").OptionRuler(), ""; got != want {
+				t.Errorf("GOT: %q; WANT: %q", got, want)
+			}
+		})
+	})
+
+	t.Run("Options", func(t *testing.T) {
+		err := goerr.New("some error message").
+			WithOptions([]string{"zero", "one", "--two"}).
+			WithOptionComment(1, "for this sub-command").
+			WithOptionComment(2, "for this option")
+
+		options := err.Options()
+		if got, want := strings.Join(options, " "), "zero one --two"; got != want {
+			t.Errorf("GOT: %q; WANT: %q", got, want)
+		}
+
+		comments := err.OptionComments()
+		if got, want := len(comments), 2; got != want {
+			t.Fatalf("GOT: %v; WANT: %v", got, want)
+		}
+		if got, want := comments[1], "for this sub-command"; got != want {
+			t.Errorf("GOT: %q; WANT: %q", got, want)
+		}
+		if got, want := comments[2], "for this option"; got != want {
+			t.Errorf("GOT: %q; WANT: %q", got, want)
+		}
+
+		// Mutating the copies must not affect the error.
+		options[0] = "mutated"
+		comments[0] = "mutated"
+		delete(comments, 1)
+
+		if got, want := err.Options()[0], "zero"; got != want {
+			t.Errorf("GOT: %q; WANT: %q", got, want)
+		}
+		if got, want := len(err.OptionComments()), 2; got != want {
+			t.Errorf("GOT: %v; WANT: %v", got, want)
+		}
+		if got, want := err.ErrorLines()[1], "zero one --two"; got != want {
+			t.Errorf("GOT: %q; WANT: %q", got, want)
+		}
+	})
+
+	t.Run("Plain", func(t *testing.T) {
+		t.Run("nil", func(t *testing.T) {
+			var ee *goerr.Error
+
+			if got, want := ee.Plain(), error(nil); got != want {
+				t.Errorf("GOT: %v; WANT: %v", got, want)
+			}
+		})
+
+		t.Run("non-nil", func(t *testing.T) {
+			err := goerr.Wrapf(fmt.Errorf("foo: %v", "bar"), "cannot configure").
+				WithExitCode(13).
+				WithTemporary(true).
+				Plain()
+
+			if _, ok := err.(*goerr.Error); ok {
+				t.Errorf("GOT: %T; WANT: not *goerr.Error", err)
+			}
+			if got, want := err.Error(), "cannot configure: foo: bar"; got != want {
+				t.Errorf("GOT: %q; WANT: %q", got, want)
+			}
+			if got, want := goerr.ExitCode(err), 0; got != want {
+				t.Errorf("GOT: %v; WANT: %v", got, want)
+			}
+			if got, want := goerr.Temporary(err), false; got != want {
+				t.Errorf("GOT: %v; WANT: %v", got, want)
+			}
+			if got, want := errors.Unwrap(err), error(nil); got != want {
+				t.Errorf("GOT: %v; WANT: %v", got, want)
+			}
+		})
+	})
+
+	t.Run("WithHelpURL", func(t *testing.T) {
+		ee := goerr.New("cannot parse config").
+			WithHelpURL("https://example.com/config")
+
+		if got, want := ee.HelpURL(), "https://example.com/config"; got != want {
+			t.Errorf("GOT: %q; WANT: %q", got, want)
+		}
+		if got, want := ee.Error(), "cannot parse config\nSee: https://example.com/config"; got != want {
+			t.Errorf("GOT: %q; WANT: %q", got, want)
+		}
+
+		t.Run("through wrap", func(t *testing.T) {
+			outer := goerr.Wrapf(fmt.Errorf("loading: %w", ee), "cannot start")
+
+			if got, want := outer.HelpURL(), "https://example.com/config"; got != want {
+				t.Errorf("GOT: %q; WANT: %q", got, want)
+			}
+		})
+
+		t.Run("sans help URL", func(t *testing.T) {
+			if got, want := goerr.New("some error").HelpURL(), ""; got != want {
+				t.Errorf("GOT: %q; WANT: %q", got, want)
+			}
+		})
+	})
+
+	t.Run("WithMaxWidth", func(t *testing.T) {
+		err := goerr.New("some error message").
+			WithOptions([]string{"zero", "one", "--two"}).
+			WithOptionComment(2, "short").
+			WithOptionComment(1, "cannot find the sub-command because it is not installed").
+			WithMaxWidth(40)
+
+		want := []string{
+			"some error message",
+			"zero one --two",
+			"         ^~~~~ short",
+			"     ^~~ cannot find the sub-command",
+			"         because it is not installed",
+		}
+
+		lines := err.ErrorLines()
+		if got, want := len(lines), len(want); got != want {
+			t.Fatalf("GOT: %q; WANT: %v lines", lines, want)
+		}
+		for i := range want {
+			if got, want := lines[i], want[i]; got != want {
+				t.Errorf("GOT: %q; WANT: %q", got, want)
+			}
+		}
+	})
+
+	t.Run("WithMessageFunc", func(t *testing.T) {
+		t.Run("nil", func(t *testing.T) {
+			err := goerr.New("some error message").WithMessageFunc(nil)
+
+			if got, want := err.Error(), "some error message"; got != want {
+				t.Errorf("GOT: %q; WANT: %q", got, want)
+			}
+		})
+
+		t.Run("non-nil", func(t *testing.T) {
+			messages := []string{"cannot configure", "impossible de configurer"}
+			var calls int
+
+			err := goerr.Wrapf(fmt.Errorf("foo: %v", "bar"), "some error message").
+				WithMessageFunc(func() string {
+					message := messages[calls%len(messages)]
+					calls++
+					return message
+				})
+
+			if got, want := err.Error(), "cannot configure: foo: bar"; got != want {
+				t.Errorf("GOT: %q; WANT: %q", got, want)
+			}
+			if got, want := err.Error(), "impossible de configurer: foo: bar"; got != want {
+				t.Errorf("GOT: %q; WANT: %q", got, want)
+			}
+		})
+	})
+
+	t.Run("NewLiteral", func(t *testing.T) {
+		ee := goerr.NewLiteral("100% failed: %s")
+
+		if got, want := ee.Error(), "100% failed: %s"; got != want {
+			t.Errorf("GOT: %q; WANT: %q", got, want)
+		}
+
+		t.Run("WithMessageLiteral", func(t *testing.T) {
+			ee := goerr.Wrap(errors.New("disk full")).WithMessageLiteral("100% used: %s")
+
+			if got, want := ee.Error(), "100% used: %s: disk full"; got != want {
+				t.Errorf("GOT: %q; WANT: %q", got, want)
+			}
+		})
+	})
+
+	t.Run("WithMessagePrefix and WithMessageSuffix", func(t *testing.T) {
+		err := goerr.Wrapf(fmt.Errorf("foo: %v", "bar"), "cannot configure").
+			WithMessagePrefix("[ERROR] ").
+			WithMessageSuffix(" (retry later)").
+			WithLineAfterOptions("after")
+
+		lines := err.ErrorLines()
+		if got, want := len(lines), 2; got != want {
+			t.Fatalf("GOT: %v; WANT: %v", got, want)
+		}
+		if got, want := lines[0], "[ERROR] cannot configure: foo: bar (retry later)"; got != want {
+			t.Errorf("GOT: %q; WANT: %q", got, want)
+		}
+		if got, want := lines[1], "after"; got != want {
+			t.Errorf("GOT: %q; WANT: %q", got, want)
+		}
+	})
+
+	t.Run("WithProgramName", func(t *testing.T) {
+		err := goerr.Wrapf(fmt.Errorf("foo: %v", "bar"), "cannot configure").
+			WithProgramName("prog").
+			WithLineBeforeMessage("before").
+			WithLineAfterOptions("after")
+
+		lines := err.ErrorLines()
+		if got, want := len(lines), 3; got != want {
+			t.Fatalf("GOT: %v; WANT: %v", got, want)
+		}
+		if got, want := lines[0], "before"; got != want {
+			t.Errorf("GOT: %q; WANT: %q", got, want)
+		}
+		if got, want := lines[1], "prog: cannot configure: foo: bar"; got != want {
+			t.Errorf("GOT: %q; WANT: %q", got, want)
+		}
+		if got, want := lines[2], "after"; got != want {
+			t.Errorf("GOT: %q; WANT: %q", got, want)
+		}
+	})
+
+	t.Run("Validate", func(t *testing.T) {
+		t.Run("valid", func(t *testing.T) {
+			err := goerr.New("some error message").
+				WithExitCode(13).
+				WithTemporary(false)
+
+			if got, want := err.Validate(), error(nil); got != want {
+				t.Errorf("GOT: %v; WANT: %v", got, want)
+			}
+		})
+
+		t.Run("temporary sans exit code", func(t *testing.T) {
+			err := goerr.New("some error message").WithTemporary(true)
+
+			if got, want := err.Validate(), error(nil); got != want {
+				t.Errorf("GOT: %v; WANT: %v", got, want)
+			}
+		})
+
+		t.Run("exit code below range", func(t *testing.T) {
+			err := goerr.New("some error message").WithExitCode(-1)
+
+			if got, want := fmt.Sprint(err.Validate()), "exit code out of range 0 through 255: -1"; got != want {
+				t.Errorf("GOT: %q; WANT: %q", got, want)
+			}
+		})
+
+		t.Run("exit code above range", func(t *testing.T) {
+			err := goerr.New("some error message").WithExitCode(256)
+
+			if got, want := fmt.Sprint(err.Validate()), "exit code out of range 0 through 255: 256"; got != want {
+				t.Errorf("GOT: %q; WANT: %q", got, want)
+			}
+		})
+
+		t.Run("temporary with nonzero exit code", func(t *testing.T) {
+			err := goerr.New("some error message").
+				WithExitCode(13).
+				WithTemporary(true)
+
+			if got, want := fmt.Sprint(err.Validate()), "temporary error with nonzero exit code: 13"; got != want {
+				t.Errorf("GOT: %q; WANT: %q", got, want)
+			}
+		})
+	})
+
+	t.Run("WithCause", func(t *testing.T) {
+		cause := fmt.Errorf("foo: %v", "bar")
+
+		ee := goerr.New("cannot configure").WithCause(cause)
+
+		if got, want := ee.Error(), goerr.Wrapf(cause, "cannot configure").Error(); got != want {
+			t.Errorf("GOT: %q; WANT: %q", got, want)
+		}
+		if got, want := ee.Unwrap(), cause; got != want {
+			t.Errorf("GOT: %v; WANT: %v", got, want)
+		}
+		if got, want := goerr.Cause(ee), cause; got != want {
+			t.Errorf("GOT: %v; WANT: %v", got, want)
+		}
+	})
+
+	t.Run("WithCollapseDuplicateCause", func(t *testing.T) {
+		ee := goerr.Wrapf(goerr.Wrap(errors.New("cannot open config")), "cannot open config")
+
+		if got, want := ee.Error(), "cannot open config: cannot open config"; got != want {
+			t.Errorf("GOT: %q; WANT: %q", got, want)
+		}
+
+		ee.WithCollapseDuplicateCause(true)
+
+		if got, want := ee.Error(), "cannot open config"; got != want {
+			t.Errorf("GOT: %q; WANT: %q", got, want)
+		}
+	})
+
+	t.Run("WithContextStack", func(t *testing.T) {
+		ee := goerr.New("cannot parse value").
+			WithContextStack([]string{"in key \"port\"", "in table \"server\""}).
+			WithContextStack([]string{"in file \"app.toml\""})
+
+		want := []string{
+			"in file \"app.toml\"",
+			"in table \"server\"",
+			"in key \"port\"",
+			"cannot parse value",
+		}
+		lines := ee.ErrorLines()
+		if got, want := len(lines), len(want); got != want {
+			t.Fatalf("GOT: %v; WANT: %v", got, want)
+		}
+		for i := range want {
+			if got, want := lines[i], want[i]; got != want {
+				t.Errorf("GOT: %q; WANT: %q", got, want)
+			}
+		}
+	})
+
+	t.Run("WithErrorf", func(t *testing.T) {
+		t.Run("sans error", func(t *testing.T) {
+			ee := goerr.Wrap(fmt.Errorf("foo: %v", "bar")).
+				WithErrorf(nil, "cannot %s", "configure")
+
+			if got, want := ee.Error(), "cannot configure: foo: bar"; got != want {
+				t.Errorf("GOT: %q; WANT: %q", got, want)
+			}
+		})
+
+		t.Run("with error", func(t *testing.T) {
+			cause := fmt.Errorf("foo: %v", "bar")
+
+			ee := goerr.New("some error message").
+				WithErrorf(cause, "cannot %s", "configure")
+
+			if got, want := ee.Error(), "cannot configure: foo: bar"; got != want {
+				t.Errorf("GOT: %q; WANT: %q", got, want)
+			}
+			if got, want := ee.Unwrap(), cause; got != want {
+				t.Errorf("GOT: %v; WANT: %v", got, want)
+			}
+		})
+
+		t.Run("nil receiver", func(t *testing.T) {
+			var ee *goerr.Error
+
+			if got, want := ee.WithErrorf(errors.New("foo"), "bar"), (*goerr.Error)(nil); got != want {
+				t.Errorf("GOT: %v; WANT: %v", got, want)
+			}
+		})
+	})
+
+	t.Run("WithSectionOrder", func(t *testing.T) {
+		err := goerr.New("some error message").
+			WithLineBeforeMessage("before").
+			WithLineBetweenMessageAndOption("between").
+			WithOptions([]string{"zero", "one"}).
+			WithOptionComment(1, "for this option").
+			WithLineAfterOptions("after")
+
+		t.Run("options first", func(t *testing.T) {
+			err.WithSectionOrder([]goerr.Section{goerr.SectionOptions, goerr.SectionMessage})
+
+			want := []string{
+				"zero one",
+				"     ^~~ for this option",
+				"some error message",
+				"before",
+				"between",
+				"after",
+			}
+
+			lines := err.ErrorLines()
+			if got, want := len(lines), len(want); got != want {
+				t.Fatalf("GOT: %q; WANT: %v lines", lines, want)
+			}
+			for i := range want {
+				if got, want := lines[i], want[i]; got != want {
+					t.Errorf("GOT: %q; WANT: %q", got, want)
+				}
+			}
+			if got, want := err.Validate(), error(nil); got != want {
+				t.Errorf("GOT: %v; WANT: %v", got, want)
+			}
+		})
+
+		t.Run("repeated section", func(t *testing.T) {
+			err.WithSectionOrder([]goerr.Section{goerr.SectionAfterOptions, goerr.SectionAfterOptions})
+
+			lines := err.ErrorLines()
+			if got, want := len(lines), 6; got != want {
+				t.Fatalf("GOT: %v; WANT: %v", got, want)
+			}
+			if got, want := lines[0], "after"; got != want {
+				t.Errorf("GOT: %q; WANT: %q", got, want)
+			}
+			if got, want := fmt.Sprint(err.Validate()), "section repeated in order: 4"; got != want {
+				t.Errorf("GOT: %q; WANT: %q", got, want)
+			}
+		})
+	})
+
+	t.Run("StrictOptionIndices", func(t *testing.T) {
+		err := goerr.New("some error message").
+			WithOptions([]string{"zero", "one"}).
+			WithOptionComment(2, "missing argument")
+
+		t.Run("lenient", func(t *testing.T) {
+			if got, want := err.Validate(), error(nil); got != want {
+				t.Errorf("GOT: %v; WANT: %v", got, want)
+			}
+
+			lines := err.ErrorLines()
+			if got, want := len(lines), 3; got != want {
+				t.Fatalf("GOT: %v; WANT: %v", got, want)
+			}
+			if got, want := lines[2], "         ^ missing argument"; got != want {
+				t.Errorf("GOT: %q; WANT: %q", got, want)
+			}
+		})
+
+		t.Run("strict", func(t *testing.T) {
+			defer func(b bool) { goerr.StrictOptionIndices = b }(goerr.StrictOptionIndices)
+			goerr.StrictOptionIndices = true
+
+			if got, want := fmt.Sprint(err.Validate()), "option comment index out of range 0 through 1: 2"; got != want {
+				t.Errorf("GOT: %q; WANT: %q", got, want)
+			}
+		})
+	})
+
+	t.Run("WithLinesCache", func(t *testing.T) {
+		ee := goerr.New("some error message").
+			WithLineAfterOptions("see usage").
+			WithLinesCache(true)
+
+		first := ee.ErrorLines()
+		second := ee.ErrorLines()
+		if got, want := len(second), 2; got != want {
+			t.Fatalf("GOT: %v; WANT: %v", got, want)
+		}
+		if &first[0] != &second[0] {
+			t.Errorf("GOT: different slices; WANT: the same slice")
+		}
+
+		ee.WithLineAfterOptions("see manual")
+
+		third := ee.ErrorLines()
+		if got, want := len(third), 3; got != want {
+			t.Fatalf("GOT: %v; WANT: %v", got, want)
+		}
+		if got, want := third[2], "see manual"; got != want {
+			t.Errorf("GOT: %q; WANT: %q", got, want)
+		}
+		if got, want := len(first), 2; got != want {
+			t.Errorf("GOT: %v; WANT: %v", got, want)
+		}
+	})
+
+	t.Run("WithMaxLines", func(t *testing.T) {
+		t.Run("under limit", func(t *testing.T) {
+			err := goerr.New("some error message").
+				WithLinesAfterOptions([]string{"line 1", "line 2"}).
+				WithMaxLines(3)
+
+			if got, want := len(err.ErrorLines()), 3; got != want {
+				t.Fatalf("GOT: %v; WANT: %v", got, want)
+			}
+		})
+
+		t.Run("over limit", func(t *testing.T) {
+			after := make([]string, 1000)
+			for i := range after {
+				after[i] = "line " + strconv.Itoa(i)
+			}
+
+			err := goerr.New("some error message").
+				WithLineBeforeMessage("before").
+				WithOptions([]string{"zero", "one"}).
+				WithLinesAfterOptions(after).
+				WithMaxLines(3)
+
+			lines := err.ErrorLines()
+			if got, want := len(lines), 3; got != want {
+				t.Fatalf("GOT: %v; WANT: %v", got, want)
+			}
+			if got, want := lines[0], "some error message"; got != want {
+				t.Errorf("GOT: %q; WANT: %q", got, want)
+			}
+			if got, want := lines[1], "zero one"; got != want {
+				t.Errorf("GOT: %q; WANT: %q", got, want)
+			}
+			if got, want := lines[2], "... (1001 more lines)"; got != want {
+				t.Errorf("GOT: %q; WANT: %q", got, want)
+			}
+		})
+
+		t.Run("limit 1", func(t *testing.T) {
+			err := goerr.New("some error message").
+				WithLineBeforeMessage("before").
+				WithLinesAfterOptions([]string{"line 1", "line 2"}).
+				WithHelpURL("https://example.com/errors/E42").
+				WithMaxLines(1)
+
+			lines := err.ErrorLines()
+			if got, want := len(lines), 1; got != want {
+				t.Fatalf("GOT: %q; WANT: %v lines", lines, want)
+			}
+			if got, want := lines[0], "some error message"; got != want {
+				t.Errorf("GOT: %q; WANT: %q", got, want)
+			}
+		})
+
+		t.Run("help URL and exit code count", func(t *testing.T) {
+			err := goerr.New("some error message").
+				WithLinesAfterOptions([]string{"line 1", "line 2"}).
+				WithHelpURL("https://example.com/errors/E42").
+				WithExitCode(42).
+				WithShowExitCode(true).
+				WithMaxLines(4)
+
+			want := []string{
+				"some error message",
+				"line 1",
+				"line 2",
+				"... (2 more lines)",
+			}
+			lines := err.ErrorLines()
+			if got, want := len(lines), len(want); got != want {
+				t.Fatalf("GOT: %q; WANT: %v lines", lines, want)
+			}
+			for i := range want {
+				if got, want := lines[i], want[i]; got != want {
+					t.Errorf("GOT: %q; WANT: %q", got, want)
+				}
+			}
+		})
+	})
+
+	t.Run("with empty options", func(t *testing.T) {
+		// An empty option occupies no columns, but is still separated from
+		// its neighbors by a space on each side.
+		err := goerr.New("some error message").
+			WithOptions([]string{"a", "", "c"}).
+			WithOptionComment(2, "third").
+			WithOptionComment(1, "second")
+
+		lines := err.ErrorLines()
+		if got, want := len(lines), 4; got != want {
+			t.Fatalf("GOT: %v; WANT: %v", got, want)
+		}
+		if got, want := lines[1], "a  c"; got != want {
+			t.Errorf("GOT: %q; WANT: %q", got, want)
+		}
+		if got, want := lines[2], "   ^ third"; got != want {
+			t.Errorf("GOT: %q; WANT: %q", got, want)
+		}
+		if got, want := lines[3], "  ^ second"; got != want {
+			t.Errorf("GOT: %q; WANT: %q", got, want)
+		}
+
+		t.Run("only", func(t *testing.T) {
+			err := goerr.New("some error message").
+				WithOptions([]string{""}).
+				WithOptionComment(0, "first").
+				WithOptionCommentAll("all")
+
+			lines := err.ErrorLines()
+			if got, want := len(lines), 4; got != want {
+				t.Fatalf("GOT: %v; WANT: %v", got, want)
+			}
+			if got, want := lines[2], "^ first"; got != want {
+				t.Errorf("GOT: %q; WANT: %q", got, want)
+			}
+			if got, want := lines[3], "^ all"; got != want {
+				t.Errorf("GOT: %q; WANT: %q", got, want)
+			}
+		})
+	})
+
+	t.Run("with single option", func(t *testing.T) {
+		for _, opt := range []string{"x", "ab", "--verbose"} {
+			t.Run(opt, func(t *testing.T) {
+				lines := goerr.New("some error message").
+					WithOptions([]string{opt}).
+					WithOptionComment(0, "for this option").
+					ErrorLines()
+
+				if got, want := len(lines), 3; got != want {
+					t.Fatalf("GOT: %v; WANT: %v", got, want)
+				}
+				want := "^" + strings.Repeat("~", len(opt)-1) + " for this option"
+				if got := lines[2]; got != want {
+					t.Errorf("GOT: %q; WANT: %q", got, want)
+				}
+			})
+		}
+	})
+
+	t.Run("with adjacent option comments", func(t *testing.T) {
+		// Each comment is displayed on its own line, so the underlines of
+		// adjacent options never merge together.
+		err := goerr.New("some error message").
+			WithOptions([]string{"a", "bc", "d"}).
+			WithOptionComment(0, "first").
+			WithOptionComment(1, "second")
+
+		lines := err.ErrorLines()
+		if got, want := len(lines), 4; got != want {
+			t.Fatalf("GOT: %v; WANT: %v", got, want)
+		}
+		if got, want := lines[1], "a bc d"; got != want {
+			t.Errorf("GOT: %q; WANT: %q", got, want)
+		}
+		if got, want := lines[2], "  ^~ second"; got != want {
+			t.Errorf("GOT: %q; WANT: %q", got, want)
+		}
+		if got, want := lines[3], "^ first"; got != want {
+			t.Errorf("GOT: %q; WANT: %q", got, want)
+		}
+	})
+
+	t.Run("WithCarets", func(t *testing.T) {
+		err := goerr.New("some error message").
+			WithOptions([]string{"zero", "one", "--two"}).
+			WithOptionComment(1, "for this sub-command").
+			WithOptionComment(2, "for this option")
+
+		t.Run("enabled", func(t *testing.T) {
+			lines := err.WithCarets(true).ErrorLines()
+			if got, want := len(lines), 4; got != want {
+				t.Fatalf("GOT: %v; WANT: %v", got, want)
+			}
+			if got, want := lines[2], "         ^~~~~ for this option"; got != want {
+				t.Errorf("GOT: %q; WANT: %q", got, want)
+			}
+			if got, want := lines[3], "     ^~~ for this sub-command"; got != want {
+				t.Errorf("GOT: %q; WANT: %q", got, want)
+			}
+		})
+
+		t.Run("disabled", func(t *testing.T) {
+			lines := err.WithCarets(false).ErrorLines()
+			if got, want := len(lines), 4; got != want {
+				t.Fatalf("GOT: %v; WANT: %v", got, want)
+			}
+			if got, want := lines[1], "zero one --two"; got != want {
+				t.Errorf("GOT: %q; WANT: %q", got, want)
+			}
+			if got, want := lines[2], "--two: for this option"; got != want {
+				t.Errorf("GOT: %q; WANT: %q", got, want)
+			}
+			if got, want := lines[3], "one: for this sub-command"; got != want {
+				t.Errorf("GOT: %q; WANT: %q", got, want)
+			}
+		})
+	})
+
+	t.Run("with ANSI colored options", func(t *testing.T) {
+		err := goerr.New("some error message").
+			WithOptions([]string{"zero", "\x1b[31mone\x1b[0m", "--two"}).
+			WithOptionComment(1, "for this sub-command").
+			WithOptionComment(2, "for this option")
+
+		lines := err.ErrorLines()
+		if got, want := len(lines), 4; got != want {
+			t.Fatalf("GOT: %v; WANT: %v", got, want)
+		}
+		if got, want := lines[2], "         ^~~~~ for this option"; got != want {
+			t.Errorf("GOT: %q; WANT: %q", got, want)
+		}
+		if got, want := lines[3], "     ^~~ for this sub-command"; got != want {
+			t.Errorf("GOT: %q; WANT: %q", got, want)
+		}
+	})
+
+	t.Run("with empty option comment", func(t *testing.T) {
+		err := goerr.New("some error message").
+			WithOptions([]string{"zero", "one", "--two"}).
+			WithOptionComment(1, "")
+
+		t.Run("with carets", func(t *testing.T) {
+			lines := err.ErrorLines()
+			if got, want := len(lines), 3; got != want {
+				t.Fatalf("GOT: %v; WANT: %v", got, want)
+			}
+			if got, want := lines[2], "     ^~~"; got != want {
+				t.Errorf("GOT: %q; WANT: %q", got, want)
+			}
+		})
+
+		t.Run("sans carets", func(t *testing.T) {
+			lines := err.WithCarets(false).ErrorLines()
+			if got, want := len(lines), 3; got != want {
+				t.Fatalf("GOT: %v; WANT: %v", got, want)
+			}
+			if got, want := lines[2], "one"; got != want {
+				t.Errorf("GOT: %q; WANT: %q", got, want)
+			}
+		})
+	})
+
+	t.Run("WithOptionCommentWide", func(t *testing.T) {
+		err := goerr.New("some error message").
+			WithOptions([]string{"zero", "one", "--two"}).
+			WithOptionCommentWide(2, "opt").
+			WithOptionCommentWide(1, "sub-command")
+
+		lines := err.ErrorLines()
+		if got, want := len(lines), 4; got != want {
+			t.Fatalf("GOT: %v; WANT: %v", got, want)
+		}
+		// Option is wider than the comment.
+		if got, want := lines[2], "         ^~~~~ opt"; got != want {
+			t.Errorf("GOT: %q; WANT: %q", got, want)
+		}
+		// Comment is wider than the option.
+		if got, want := lines[3], "     ^~~~~~~~~~~ sub-command"; got != want {
+			t.Errorf("GOT: %q; WANT: %q", got, want)
+		}
+	})
+
+	t.Run("WithOption", func(t *testing.T) {
+		options := []string{"zero", "one", "--two", "three"}
+
+		want := goerr.New("some error message").
+			WithOptions(options).
+			WithOptionComment(2, "for this option").
+			ErrorLines()
+
+		err := goerr.New("some error message").
+			WithOption("zero").
+			WithOption("one").
+			WithOptionComment(2, "for this option").
+			WithOptionsAppend("--two", "three")
+
+		got := err.ErrorLines()
+		if len(got) != len(want) {
+			t.Fatalf("GOT: %q; WANT: %q", got, want)
+		}
+		for i := range want {
+			if got[i] != want[i] {
+				t.Errorf("GOT: %q; WANT: %q", got[i], want[i])
+			}
+		}
+
+		t.Run("does not modify caller slice", func(t *testing.T) {
+			err := goerr.New("some error message").
+				WithOptions(options[:2]).
+				WithOption("mutated")
+
+			if got, want := options[2], "--two"; got != want {
+				t.Errorf("GOT: %q; WANT: %q", got, want)
+			}
+			if got, want := err.ErrorLines()[1], "zero one mutated"; got != want {
+				t.Errorf("GOT: %q; WANT: %q", got, want)
+			}
+		})
+	})
+
+	t.Run("WithOptionCommentIdx", func(t *testing.T) {
+		const two goerr.OptionIndex = 2
+
+		got := goerr.New("some error message").
+			WithOptions([]string{"zero", "one", "--two"}).
+			WithOptionCommentIdx(two, "for this option").
+			ErrorLines()
+
+		want := goerr.New("some error message").
+			WithOptions([]string{"zero", "one", "--two"}).
+			WithOptionComment(2, "for this option").
+			ErrorLines()
+
+		if len(got) != len(want) {
+			t.Fatalf("GOT: %q; WANT: %q", got, want)
+		}
+		for i := range want {
+			if got[i] != want[i] {
+				t.Errorf("GOT: %q; WANT: %q", got[i], want[i])
+			}
+		}
+	})
+
+	t.Run("WithOptionSuggestion", func(t *testing.T) {
+		ee := goerr.New("unknown flag").
+			WithOptions([]string{"ls", "--verbos"}).
+			WithOptionSuggestion(1, "--verbose")
+
+		lines := ee.ErrorLines()
+		if got, want := len(lines), 3; got != want {
+			t.Fatalf("GOT: %v; WANT: %v", got, want)
+		}
+		if got, want := lines[2], "   ^~~~~~~~ did you mean --verbose?"; got != want {
+			t.Errorf("GOT: %q; WANT: %q", got, want)
+		}
+	})
+
+	t.Run("WithOptionsQuoted", func(t *testing.T) {
+		err := goerr.New("some error message").
+			WithOptions([]string{"zero", "has space", "it's", "--two"}).
+			WithOptionComment(3, "for this option").
+			WithOptionComment(1, "for this argument").
+			WithOptionsQuoted(true)
+
+		want := []string{
+			"some error message",
+			`zero 'has space' 'it'\''s' --two`,
+			"                           ^~~~~ for this option",
+			"     ^~~~~~~~~~~ for this argument",
+		}
+
+		lines := err.ErrorLines()
+		if got, want := len(lines), len(want); got != want {
+			t.Fatalf("GOT: %q; WANT: %v lines", lines, want)
+		}
+		for i := range want {
+			if got, want := lines[i], want[i]; got != want {
+				t.Errorf("GOT: %q; WANT: %q", got, want)
+			}
+		}
+	})
+
+	t.Run("WithOptionCommentAt", func(t *testing.T) {
+		t.Run("within option", func(t *testing.T) {
+			err := goerr.New("some error message").
+				WithOptions([]string{"zero", "--flag=ba$d", "two"}).
+				WithOptionComment(2, "for this argument").
+				WithOptionCommentAt(1, 9, 1, "invalid character")
+
+			lines := err.ErrorLines()
+			if got, want := len(lines), 4; got != want {
+				t.Fatalf("GOT: %v; WANT: %v", got, want)
+			}
+			if got, want := lines[1], "zero --flag=ba$d two"; got != want {
+				t.Errorf("GOT: %q; WANT: %q", got, want)
+			}
+			if got, want := lines[2], "                 ^~~ for this argument"; got != want {
+				t.Errorf("GOT: %q; WANT: %q", got, want)
+			}
+			if got, want := lines[3], "              ^ invalid character"; got != want {
+				t.Errorf("GOT: %q; WANT: %q", got, want)
+			}
+		})
+
+		t.Run("wide runes", func(t *testing.T) {
+			err := goerr.New("some error message").
+				WithOptions([]string{"cat", "文件名.txt"}).
+				WithOptionCommentAt(1, 3, 4, "not found")
+
+			lines := err.ErrorLines()
+			if got, want := len(lines), 3; got != want {
+				t.Fatalf("GOT: %v; WANT: %v", got, want)
+			}
+			if got, want := lines[2], "          ^~~~ not found"; got != want {
+				t.Errorf("GOT: %q; WANT: %q", got, want)
+			}
+		})
+
+		t.Run("within quoted option", func(t *testing.T) {
+			err := goerr.New("some error message").
+				WithOptions([]string{"a", "it's"}).
+				WithOptionCommentAt(1, 3, 1, "s").
+				WithOptionCommentAt(1, 1, 3, "t's").
+				WithOptionsQuoted(true)
+
+			want := []string{
+				"some error message",
+				`a 'it'\''s'`,
+				"         ^ s",
+				"    ^~~~~~ t's",
+			}
+			lines := err.ErrorLines()
+			if got, want := len(lines), len(want); got != want {
+				t.Fatalf("GOT: %q; WANT: %v lines", lines, want)
+			}
+			for i := range want {
+				if got, want := lines[i], want[i]; got != want {
+					t.Errorf("GOT: %q; WANT: %q", got, want)
+				}
+			}
+		})
+
+		t.Run("wide runes within quoted option", func(t *testing.T) {
+			err := goerr.New("some error message").
+				WithOptions([]string{"cat", "文 件"}).
+				WithOptionCommentAt(1, 2, 1, "second").
+				WithOptionsQuoted(true)
+
+			lines := err.ErrorLines()
+			if got, want := len(lines), 3; got != want {
+				t.Fatalf("GOT: %q; WANT: %v lines", lines, want)
+			}
+			if got, want := lines[2], "        ^~ second"; got != want {
+				t.Errorf("GOT: %q; WANT: %q", got, want)
+			}
+		})
+
+		t.Run("beyond option", func(t *testing.T) {
+			err := goerr.New("some error message").
+				WithOptions([]string{"zero", "--flag=ba$d", "two"}).
+				WithOptionCommentAt(1, 7, 10, "invalid value")
+
+			lines := err.ErrorLines()
+			if got, want := len(lines), 3; got != want {
+				t.Fatalf("GOT: %v; WANT: %v", got, want)
+			}
+			if got, want := lines[2], "            ^~~~ invalid value"; got != want {
+				t.Errorf("GOT: %q; WANT: %q", got, want)
+			}
+		})
+	})
+
+	t.Run("CaretFormatter", func(t *testing.T) {
+		defer func(fn func(int, int, string) string) { goerr.CaretFormatter = fn }(goerr.CaretFormatter)
+		goerr.CaretFormatter = func(col, width int, comment string) string {
+			return fmt.Sprintf("[col=%d] %s", col, comment)
+		}
+
+		lines := goerr.New("some error message").
+			WithOptions([]string{"zero", "one", "--two"}).
+			WithOptionComment(2, "for this option").
+			ErrorLines()
+
+		if got, want := len(lines), 3; got != want {
+			t.Fatalf("GOT: %v; WANT: %v", got, want)
+		}
+		if got, want := lines[2], "[col=9] for this option"; got != want {
+			t.Errorf("GOT: %q; WANT: %q", got, want)
+		}
+	})
+
+	t.Run("WithCommandPrefix", func(t *testing.T) {
+		lines := goerr.New("unknown flag").
+			WithOptions([]string{"git", "commit", "--amned"}).
+			WithCommandPrefix(2).
+			WithOptionComment(0, "did you mean --amend?").
+			ErrorLines()
+
+		want := []string{
+			"unknown flag",
+			"git commit --amned",
+			"           ^~~~~~~ did you mean --amend?",
+		}
+		if got, want := len(lines), len(want); got != want {
+			t.Fatalf("GOT: %v; WANT: %v", got, want)
+		}
+		for i := range want {
+			if got, want := lines[i], want[i]; got != want {
+				t.Errorf("GOT: %q; WANT: %q", got, want)
+			}
+		}
+	})
+
+	t.Run("WithCommandPrefix and Append", func(t *testing.T) {
+		other := goerr.New("other").
+			WithOptions([]string{"--x"}).
+			WithOptionComment(0, "unknown flag")
+
+		lines := goerr.New("unknown flag").
+			WithOptions([]string{"git", "commit", "--amned"}).
+			WithCommandPrefix(2).
+			Append(other).
+			ErrorLines()
+
+		if got, want := len(lines), 3; got != want {
+			t.Fatalf("GOT: %q; WANT: %v lines", lines, want)
+		}
+		if got, want := lines[1], "git commit --amned --x"; got != want {
+			t.Errorf("GOT: %q; WANT: %q", got, want)
+		}
+		if got, want := lines[2], "                   ^~~ unknown flag"; got != want {
+			t.Errorf("GOT: %q; WANT: %q", got, want)
+		}
+	})
+
+	t.Run("WithCommandPrefix out of range", func(t *testing.T) {
+		ee := goerr.New("unknown flag").
+			WithOptions([]string{"git"}).
+			WithCommandPrefix(-1).
+			WithOptionComment(0, "here")
+
+		if got, want := ee.ErrorLines()[2], "^~~ here"; got != want {
+			t.Errorf("GOT: %q; WANT: %q", got, want)
+		}
+		if err := ee.Validate(); err != nil {
+			t.Errorf("GOT: %v; WANT: nil", err)
+		}
+
+		ee.WithCommandPrefix(2)
+
+		if err := ee.Validate(); err == nil {
+			t.Errorf("GOT: nil; WANT: error")
+		}
+	})
+
+	t.Run("WithCommentConnector", func(t *testing.T) {
+		err := goerr.New("some error message").
+			WithOptions([]string{"zero", "one", "--two"}).
+			WithOptionComment(1, "for this sub-command")
+
+		t.Run("arrow", func(t *testing.T) {
+			lines := err.WithCommentConnector(" => ").ErrorLines()
+			if got, want := len(lines), 3; got != want {
+				t.Fatalf("GOT: %v; WANT: %v", got, want)
+			}
+			if got, want := lines[2], "     ^~~ => for this sub-command"; got != want {
+				t.Errorf("GOT: %q; WANT: %q", got, want)
+			}
+		})
+
+		t.Run("empty", func(t *testing.T) {
+			lines := err.WithCommentConnector("").ErrorLines()
+			if got, want := len(lines), 3; got != want {
+				t.Fatalf("GOT: %v; WANT: %v", got, want)
+			}
+			if got, want := lines[2], "     ^~~for this sub-command"; got != want {
+				t.Errorf("GOT: %q; WANT: %q", got, want)
+			}
+		})
+	})
+
+	t.Run("WithCommentLegend", func(t *testing.T) {
+		t.Run("separated", func(t *testing.T) {
+			err := goerr.New("some error message").
+				WithOptions([]string{"zero", "one", "--two", "three"}).
+				WithOptionComment(3, "cannot find this file").
+				WithOptionComment(1, "for this sub-command").
+				WithOptionComment(2, "for this option").
+				WithCommentLegend(true)
+
+			lines := err.ErrorLines()
+			if got, want := len(lines), 6; got != want {
+				t.Fatalf("GOT: %v; WANT: %v", got, want)
+			}
+			if got, want := lines[1], "zero one --two three"; got != want {
+				t.Errorf("GOT: %q; WANT: %q", got, want)
+			}
+			if got, want := lines[2], "     (1) (2)   (3)"; got != want {
+				t.Errorf("GOT: %q; WANT: %q", got, want)
+			}
+			if got, want := lines[3], "(1) for this sub-command"; got != want {
+				t.Errorf("GOT: %q; WANT: %q", got, want)
+			}
+			if got, want := lines[4], "(2) for this option"; got != want {
+				t.Errorf("GOT: %q; WANT: %q", got, want)
+			}
+			if got, want := lines[5], "(3) cannot find this file"; got != want {
+				t.Errorf("GOT: %q; WANT: %q", got, want)
+			}
+		})
+
+		t.Run("overlapping", func(t *testing.T) {
+			err := goerr.New("some error message").
+				WithOptions([]string{"a", "b", "c"}).
+				WithOptionComment(0, "first").
+				WithOptionComment(1, "second").
+				WithCommentLegend(true)
+
+			lines := err.ErrorLines()
+			if got, want := len(lines), 5; got != want {
+				t.Fatalf("GOT: %v; WANT: %v", got, want)
+			}
+			if got, want := lines[2], "(1) (2)"; got != want {
+				t.Errorf("GOT: %q; WANT: %q", got, want)
+			}
+		})
+	})
+
+	t.Run("WithCommentsAscending", func(t *testing.T) {
+		lines := goerr.New("some error message").
+			WithOptions([]string{"zero", "one", "--two", "three"}).
+			WithOptionComment(3, "cannot find this file").
+			WithOptionComment(1, "for this sub-command").
+			WithOptionComment(2, "for this option").
+			WithCommentsAscending(true).
+			ErrorLines()
+
+		want := []string{
+			"some error message",
+			"zero one --two three",
+			"     ^~~ for this sub-command",
+			"         ^~~~~ for this option",
+			"               ^~~~~ cannot find this file",
+		}
+		if got, want := len(lines), len(want); got != want {
+			t.Fatalf("GOT: %v; WANT: %v", got, want)
+		}
+		for i := range want {
+			if got, want := lines[i], want[i]; got != want {
+				t.Errorf("GOT: %q; WANT: %q", got, want)
+			}
+		}
+	})
+
+	t.Run("WithGapComment", func(t *testing.T) {
+		err := goerr.New("some error message").
+			WithOptions([]string{"zero", "--one", "two"}).
+			WithOptionComment(1, "this option requires two arguments").
+			WithGapComment(1, "missing argument")
+
+		t.Run("with carets", func(t *testing.T) {
+			want := []string{
+				"some error message",
+				"zero --one two",
+				"          ^ missing argument",
+				"     ^~~~~ this option requires two arguments",
+			}
+
+			lines := err.ErrorLines()
+			if got, want := len(lines), len(want); got != want {
+				t.Fatalf("GOT: %q; WANT: %v lines", lines, want)
+			}
+			for i := range want {
+				if got, want := lines[i], want[i]; got != want {
+					t.Errorf("GOT: %q; WANT: %q", got, want)
+				}
+			}
+		})
+
+		t.Run("sans carets", func(t *testing.T) {
+			lines := err.WithCarets(false).ErrorLines()
+			if got, want := len(lines), 4; got != want {
+				t.Fatalf("GOT: %v; WANT: %v", got, want)
+			}
+			if got, want := lines[2], "after --one: missing argument"; got != want {
+				t.Errorf("GOT: %q; WANT: %q", got, want)
+			}
+		})
+	})
+
+	t.Run("WithOptionCommentAll", func(t *testing.T) {
+		err := goerr.New("some error message").
+			WithOptions([]string{"zero", "one", "--two"}).
+			WithOptionCommentAll("3 arguments invalid").
+			WithOptionComment(1, "for this option")
+
+		lines := err.ErrorLines()
+		if got, want := len(lines), 4; got != want {
+			t.Fatalf("GOT: %v; WANT: %v", got, want)
+		}
+		if got, want := lines[1], "zero one --two"; got != want {
+			t.Errorf("GOT: %q; WANT: %q", got, want)
+		}
+		if got, want := lines[2], "     ^~~ for this option"; got != want {
+			t.Errorf("GOT: %q; WANT: %q", got, want)
+		}
+		if got, want := lines[3], "^~~~~~~~~~~~~~ 3 arguments invalid"; got != want {
+			t.Errorf("GOT: %q; WANT: %q", got, want)
+		}
+	})
+
+	t.Run("ErrorLinesVerbose", func(t *testing.T) {
+		// Build a chain five errors deep.
+		err := goerr.Wrapf(
+			goerr.Wrapf(
+				&dummyUnwrapper{
+					err: goerr.Wrapf(errors.New("level 4"), "level 3"),
+				},
+				"level 1",
+			),
+			"level 0",
+		)
+
+		t.Run("unlimited", func(t *testing.T) {
+			lines := err.ErrorLinesVerbose()
+			if got, want := len(lines), 5; got != want {
+				t.Fatalf("GOT: %v; WANT: %v", got, want)
+			}
+			if got, want := lines[0], "level 0: level 1: unwraps err: level 3: level 4"; got != want {
+				t.Errorf("GOT: %q; WANT: %q", got, want)
+			}
+			if got, want := lines[1], "caused by: level 1: unwraps err: level 3: level 4"; got != want {
+				t.Errorf("GOT: %q; WANT: %q", got, want)
+			}
+			if got, want := lines[2], "caused by: unwraps err: level 3: level 4"; got != want {
+				t.Errorf("GOT: %q; WANT: %q", got, want)
+			}
+			if got, want := lines[3], "caused by: level 3: level 4"; got != want {
+				t.Errorf("GOT: %q; WANT: %q", got, want)
+			}
+			if got, want := lines[4], "caused by: level 4"; got != want {
+				t.Errorf("GOT: %q; WANT: %q", got, want)
+			}
+		})
+
+		t.Run("Format", func(t *testing.T) {
+			ee := goerr.Wrapf(
+				goerr.Wrapf(errors.New("level 2"), "level 1"),
+				"level 0",
+			).WithLineAfterOptions("see usage")
+
+			want := strings.Join([]string{
+				"level 0: level 1: level 2",
+				"see usage",
+				"caused by: level 1: level 2",
+				"caused by: level 2",
+			}, "\n")
+			if got := fmt.Sprintf("%+v", ee); got != want {
+				t.Errorf("GOT: %q; WANT: %q", got, want)
+			}
+			if got, want := fmt.Sprintf("%v", ee), ee.Error(); got != want {
+				t.Errorf("GOT: %q; WANT: %q", got, want)
+			}
+			if got, want := fmt.Sprintf("%q", ee), strconv.Quote(ee.Error()); got != want {
+				t.Errorf("GOT: %q; WANT: %q", got, want)
+			}
+		})
+
+		t.Run("MaxRenderDepth", func(t *testing.T) {
+			defer func(n int) { goerr.MaxRenderDepth = n }(goerr.MaxRenderDepth)
+			goerr.MaxRenderDepth = 2
+
+			lines := err.ErrorLinesVerbose()
+			if got, want := len(lines), 4; got != want {
+				t.Fatalf("GOT: %v; WANT: %v", got, want)
+			}
+			if got, want := lines[2], "caused by: unwraps err: level 3: level 4"; got != want {
+				t.Errorf("GOT: %q; WANT: %q", got, want)
+			}
+			if got, want := lines[3], "... (more causes)"; got != want {
+				t.Errorf("GOT: %q; WANT: %q", got, want)
+			}
+		})
+	})
+
+	t.Run("ExitCode", func(t *testing.T) {
+		t.Run("sans exit code", func(t *testing.T) {
+			var ee goerr.Error
+
+			if got, want := ee.ExitCode(), 0; got != want {
+				t.Errorf("GOT: %v; WANT: %v", got, want)
+			}
+		})
+
+		t.Run("with exit code", func(t *testing.T) {
+			ee := new(goerr.Error)
+
+			ee = ee.WithExitCode(13)
+
+			if got, want := ee.ExitCode(), 13; got != want {
+				t.Errorf("GOT: %v; WANT: %v", got, want)
+			}
+		})
+	})
+
+	t.Run("WithExitCodeClamp", func(t *testing.T) {
+		for _, tc := range []struct {
+			code, want int
+		}{
+			{-5, 0},
+			{0, 0},
+			{13, 13},
+			{255, 255},
+			{300, 255},
+		} {
+			ee := goerr.New("some error message").WithExitCodeClamp(tc.code)
+
+			if got, want := ee.ExitCode(), tc.want; got != want {
+				t.Errorf("GOT: %v; WANT: %v", got, want)
+			}
+			if got, want := ee.Validate(), error(nil); got != want {
+				t.Errorf("GOT: %v; WANT: %v", got, want)
+			}
+		}
+
+		t.Run("nil receiver", func(t *testing.T) {
+			var ee *goerr.Error
+
+			if got, want := ee.WithExitCodeClamp(300), (*goerr.Error)(nil); got != want {
+				t.Errorf("GOT: %v; WANT: %v", got, want)
+			}
+		})
+	})
+
+	t.Run("WithExitCodeFunc", func(t *testing.T) {
+		status := 1
+
+		ee := goerr.New("some error message").
+			WithExitCode(13).
+			WithExitCodeFunc(func() int { return status })
+
+		status = 75 // changed after wrapping
+
+		if got, want := ee.ExitCode(), 75; got != want {
+			t.Errorf("GOT: %v; WANT: %v", got, want)
+		}
+		if got, want := goerr.ExitCode(fmt.Errorf("wrapped: %w", ee)), 75; got != want {
+			t.Errorf("GOT: %v; WANT: %v", got, want)
+		}
+
+		t.Run("nil fn", func(t *testing.T) {
+			ee.WithExitCodeFunc(nil)
+
+			if got, want := ee.ExitCode(), 13; got != want {
+				t.Errorf("GOT: %v; WANT: %v", got, want)
+			}
+		})
+	})
+
+	t.Run("WithExitCodeFrom", func(t *testing.T) {
+		t.Run("nil other", func(t *testing.T) {
+			ee := goerr.New("some error message").
+				WithExitCode(13).
+				WithExitCodeFrom(nil)
+
+			if got, want := ee.ExitCode(), 13; got != want {
+				t.Errorf("GOT: %v; WANT: %v", got, want)
+			}
+		})
+
+		t.Run("configured other", func(t *testing.T) {
+			other := goerr.New("other").
+				WithExitCode(13).
+				WithTemporary(true)
+
+			ee := goerr.Wrap(&dummyExitCoder{code: 42}).WithExitCodeFrom(other)
+
+			if got, want := ee.ExitCode(), 13; got != want {
+				t.Errorf("GOT: %v; WANT: %v", got, want)
+			}
+			if got, want := ee.Temporary(), true; got != want {
+				t.Errorf("GOT: %v; WANT: %v", got, want)
+			}
+		})
+
+		t.Run("unset other", func(t *testing.T) {
+			ee := goerr.Wrap(&dummyExitCoder{code: 42}).
+				WithExitCode(13).
+				WithExitCodeFrom(goerr.New("other"))
+
+			// Exit code of other is unset, so this resolves the wrapped error.
+			if got, want := ee.ExitCode(), 42; got != want {
+				t.Errorf("GOT: %v; WANT: %v", got, want)
+			}
+		})
+
+		t.Run("stop inheritance other", func(t *testing.T) {
+			ee := goerr.Wrap(&dummyExitCoder{code: 42}).
+				WithExitCodeFrom(goerr.New("other").WithStopCodeInheritance())
+
+			if got, want := ee.ExitCode(), 0; got != want {
+				t.Errorf("GOT: %v; WANT: %v", got, want)
+			}
+		})
+
+		t.Run("warning other", func(t *testing.T) {
+			ee := goerr.Wrap(&dummyExitCoder{code: 42}).
+				WithExitCodeFrom(goerr.New("other").WithWarning("deprecated flag"))
+
+			if got, want := ee.ExitCode(), 0; got != want {
+				t.Errorf("GOT: %v; WANT: %v", got, want)
+			}
+		})
+	})
+
+	t.Run("WithShowExitCode", func(t *testing.T) {
+		ee := goerr.New("some error message").
+			WithLineAfterOptions("see usage").
+			WithExitCode(13)
+
+		if got, want := ee.Error(), "some error message\nsee usage"; got != want {
+			t.Errorf("GOT: %q; WANT: %q", got, want)
+		}
+
+		ee.WithShowExitCode(true)
+
+		if got, want := ee.Error(), "some error message\nsee usage\nexit code: 13"; got != want {
+			t.Errorf("GOT: %q; WANT: %q", got, want)
+		}
+
+		t.Run("sans exit code", func(t *testing.T) {
+			ee := goerr.New("some error message").WithShowExitCode(true)
+
+			if got, want := ee.Error(), "some error message"; got != want {
+				t.Errorf("GOT: %q; WANT: %q", got, want)
+			}
+		})
+	})
+
+	t.Run("WithSnippet", func(t *testing.T) {
+		ee := goerr.New("cannot load config.toml").
+			WithSnippet("timeout = 3x", 10, 2, "invalid duration")
+
+		if got, want := ee.Error(), "cannot load config.toml\ntimeout = 3x\n          ^~ invalid duration"; got != want {
+			t.Errorf("GOT: %q; WANT: %q", got, want)
+		}
+	})
+
+	t.Run("WithStopCodeInheritance", func(t *testing.T) {
+		ee := goerr.Wrap(&dummyExitCoder{code: 42})
+
+		if got, want := ee.ExitCode(), 42; got != want {
+			t.Errorf("GOT: %v; WANT: %v", got, want)
+		}
+
+		ee.WithStopCodeInheritance()
+
+		if got, want := ee.ExitCode(), 0; got != want {
+			t.Errorf("GOT: %v; WANT: %v", got, want)
+		}
+		if got, want := goerr.ExitCode(fmt.Errorf("wrapped: %w", ee)), 0; got != want {
+			t.Errorf("GOT: %v; WANT: %v", got, want)
+		}
+
+		t.Run("own exit code", func(t *testing.T) {
+			ee.WithExitCode(13)
+
+			if got, want := ee.ExitCode(), 13; got != want {
+				t.Errorf("GOT: %v; WANT: %v", got, want)
+			}
+		})
+	})
+
+	t.Run("WithTrimComments", func(t *testing.T) {
+		ee := goerr.New("some error message").
+			WithOptions([]string{"zero", "one"}).
+			WithOptionComment(1, "for this option  \t").
+			WithLineAfterOptions("see usage   ")
+
+		if got, want := ee.Error(), "some error message\nzero one\n     ^~~ for this option  \t\nsee usage   "; got != want {
+			t.Errorf("GOT: %q; WANT: %q", got, want)
+		}
+
+		ee.WithTrimComments(true)
+
+		if got, want := ee.Error(), "some error message\nzero one\n     ^~~ for this option\nsee usage"; got != want {
+			t.Errorf("GOT: %q; WANT: %q", got, want)
+		}
+	})
+
+	t.Run("WithTruncate CJK", func(t *testing.T) {
+		lines := goerr.New("some error message").
+			WithLineAfterOptions("文件名无法读取").
+			WithTruncate(6).
+			ErrorLines()
+
+		if got, want := lines[len(lines)-1], "文件…"; got != want {
+			t.Errorf("GOT: %q; WANT: %q", got, want)
+		}
+	})
+
+	t.Run("WithTruncate", func(t *testing.T) {
+		ee := goerr.New("some error message").
+			WithOptions([]string{"zero", "--one-two-three"}).
+			WithOptionComment(1, "for this option").
+			WithLineBeforeMessage("short").
+			WithLineAfterOptions("\x1b[31mthis line is too long\x1b[0m").
+			WithTruncate(10)
+
+		want := []string{
+			"short",
+			"some error message",
+			"zero --one-two-three",
+			"     ^~~~~~~~~~~~~~~ for this option",
+			"\x1b[31mthis line…\x1b[0m",
+		}
+		lines := ee.ErrorLines()
+		if got, want := len(lines), len(want); got != want {
+			t.Fatalf("GOT: %v; WANT: %v", got, want)
+		}
+		for i := range want {
+			if got, want := lines[i], want[i]; got != want {
+				t.Errorf("GOT: %q; WANT: %q", got, want)
+			}
+		}
+	})
+
+	t.Run("WithReplaceOptions", func(t *testing.T) {
+		options := []string{"zero", "--one"}
+
+		err := goerr.New("some error message").
+			WithOptions([]string{"zero", "one", "--two"}).
+			WithOptionComment(2, "for this option").
+			WithReplaceOptions(options)
+
+		options[0] = "mutated"
+
+		lines := err.ErrorLines()
+		if got, want := len(lines), 2; got != want {
+			t.Fatalf("GOT: %v; WANT: %v", got, want)
+		}
+		if got, want := lines[1], "zero --one"; got != want {
+			t.Errorf("GOT: %q; WANT: %q", got, want)
+		}
+		if got, want := len(err.OptionComments()), 0; got != want {
+			t.Errorf("GOT: %v; WANT: %v", got, want)
+		}
+	})
+
+	t.Run("WithTemporaryUntil", func(t *testing.T) {
+		clock := time.Date(2024, time.November, 5, 12, 0, 0, 0, time.UTC)
+		defer goerr.SetNow(func() time.Time { return clock })()
+
+		err := goerr.New("some error message").
+			WithTemporaryUntil(clock.Add(time.Minute))
+
+		if got, want := err.Temporary(), true; got != want {
+			t.Errorf("GOT: %v; WANT: %v", got, want)
+		}
+		if got, want := goerr.Temporary(err), true; got != want {
+			t.Errorf("GOT: %v; WANT: %v", got, want)
+		}
+
+		clock = clock.Add(time.Minute)
+
+		if got, want := err.Temporary(), false; got != want {
+			t.Errorf("GOT: %v; WANT: %v", got, want)
+		}
+		if got, want := goerr.Temporary(err), false; got != want {
+			t.Errorf("GOT: %v; WANT: %v", got, want)
+		}
+
+		// WithTemporary removes the deadline.
+		err.WithTemporary(true)
+
+		if got, want := err.Temporary(), true; got != want {
+			t.Errorf("GOT: %v; WANT: %v", got, want)
+		}
+	})
+
+	t.Run("Unwrap", func(t *testing.T) {
+		t.Run("sans wrapped error", func(t *testing.T) {
+			var ee goerr.Error
+
+			if got, want := ee.Unwrap(), error(nil); got != want {
+				t.Errorf("GOT: %v; WANT: %v", got, want)
+			}
+		})
+
+		t.Run("with wrapped error", func(t *testing.T) {
+			ee := goerr.Wrap(fmt.Errorf("foo: %v", "bar"))
+
+			if got, want := ee.Unwrap().Error(), "foo: bar"; got != want {
+				t.Errorf("GOT: %v; WANT: %v", got, want)
+			}
+		})
+	})
+}
+
+func BenchmarkError(b *testing.B) {
+	b.Run("message only", func(b *testing.B) {
+		err := goerr.Wrapf(fmt.Errorf("foo: %v", "bar"), "cannot configure")
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			_ = err.Error()
+		}
+	})
+
+	b.Run("with lines", func(b *testing.B) {
+		err := goerr.Wrapf(fmt.Errorf("foo: %v", "bar"), "cannot configure").
+			WithLineAfterOptions("line 1")
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			_ = err.Error()
+		}
 	})
 }
@@ -0,0 +1,25 @@
+package goerr
+
+import "time"
+
+// SetNow replaces the clock used by this package with fn, and returns a
+// function that restores the original clock.
+func SetNow(fn func() time.Time) func() {
+	original := now
+	now = fn
+	return func() { now = original }
+}
+
+// ResetRegistry removes every sentinel registered by Register, and returns a
+// function that restores the original registrations.
+func ResetRegistry() func() {
+	registryMu.Lock()
+	original := registry
+	registry = nil
+	registryMu.Unlock()
+	return func() {
+		registryMu.Lock()
+		registry = original
+		registryMu.Unlock()
+	}
+}
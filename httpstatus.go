@@ -0,0 +1,35 @@
+package goerr
+
+// ExitCodeFromHTTPStatus returns an exit code, following the conventions of
+// sysexits.h, that corresponds to the HTTP status code status:
+//
+//	100-399        0   (success)
+//	401, 403       77  EX_NOPERM
+//	408, 429       75  EX_TEMPFAIL
+//	other 400-499  64  EX_USAGE
+//	503, 504       75  EX_TEMPFAIL
+//	other 500-599  70  EX_SOFTWARE
+//	other          1
+func ExitCodeFromHTTPStatus(status int) int {
+	switch {
+	case status >= 100 && status < 400:
+		return 0
+	case status == 401 || status == 403:
+		return 77
+	case status == 408 || status == 429 || status == 503 || status == 504:
+		return 75
+	case status >= 400 && status < 500:
+		return 64
+	case status >= 500 && status < 600:
+		return 70
+	default:
+		return 1
+	}
+}
+
+// WithExitCodeFromHTTPStatus stores the exit code that corresponds to the
+// HTTP status code status, as returned by ExitCodeFromHTTPStatus, as the value
+// to be returned by the ExitCode method.
+func (e *Error) WithExitCodeFromHTTPStatus(status int) *Error {
+	return e.WithExitCode(ExitCodeFromHTTPStatus(status))
+}
@@ -0,0 +1,35 @@
+package goerr_test
+
+import (
+	"testing"
+
+	"github.com/karrick/goerr"
+)
+
+func TestExitCodeFromHTTPStatus(t *testing.T) {
+	tests := []struct {
+		status int
+		want   int
+	}{
+		{200, 0},
+		{404, 64},
+		{429, 75},
+		{500, 70},
+		{503, 75},
+		{999, 1},
+	}
+
+	for _, tt := range tests {
+		if got, want := goerr.ExitCodeFromHTTPStatus(tt.status), tt.want; got != want {
+			t.Errorf("%d: GOT: %v; WANT: %v", tt.status, got, want)
+		}
+	}
+
+	t.Run("WithExitCodeFromHTTPStatus", func(t *testing.T) {
+		ee := goerr.New("cannot fetch").WithExitCodeFromHTTPStatus(404)
+
+		if got, want := ee.ExitCode(), 64; got != want {
+			t.Errorf("GOT: %v; WANT: %v", got, want)
+		}
+	})
+}
@@ -0,0 +1,23 @@
+package goerr
+
+// ToMap returns a map with the message of this instance under the "message"
+// key, along with its exit code, temporary value, help URL, and the message
+// of the wrapped error under the "exit_code", "temporary", "help_url", and
+// "cause" keys when each is set, which may be spread into the fields of a
+// structured log entry.
+func (e Error) ToMap() map[string]any {
+	m := map[string]any{"message": e.ownMessage()}
+	if code, ok := e.ownExitCode(); ok {
+		m["exit_code"] = code
+	}
+	if e.isTemporarySet {
+		m["temporary"] = e.ownTemporary()
+	}
+	if e.helpURL != "" {
+		m["help_url"] = e.helpURL
+	}
+	if e.err != nil {
+		m["cause"] = e.err.Error()
+	}
+	return m
+}
@@ -0,0 +1,30 @@
+package goerr_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/karrick/goerr"
+)
+
+func TestToMap(t *testing.T) {
+	m := goerr.Wrapf(errors.New("permission denied"), "cannot open config").
+		WithExitCode(77).
+		WithTemporary(false).
+		ToMap()
+
+	want := map[string]any{
+		"message":   "cannot open config",
+		"exit_code": 77,
+		"temporary": false,
+		"cause":     "permission denied",
+	}
+	if got, want := len(m), len(want); got != want {
+		t.Fatalf("GOT: %v; WANT: %v", got, want)
+	}
+	for k, v := range want {
+		if got, want := m[k], v; got != want {
+			t.Errorf("%s: GOT: %v; WANT: %v", k, got, want)
+		}
+	}
+}
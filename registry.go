@@ -0,0 +1,73 @@
+package goerr
+
+import (
+	"reflect"
+	"sync"
+)
+
+type registration struct {
+	sentinel  error
+	exitCode  int
+	temporary bool
+}
+
+var (
+	registryMu sync.RWMutex
+	registry   []registration
+)
+
+// Register records the exit code and temporary values for sentinel, which
+// ExitCode and Temporary return for an error that matches sentinel according
+// to errors.Is, when no error in its chain provides its own value. When an
+// error matches more than one registered sentinel, the one registered first
+// is used. Registering a sentinel again replaces its values. A sentinel whose
+// type is not comparable is only matched by the Is method of an error, and is
+// never replaced. It panics when sentinel is nil.
+//
+//	goerr.Register(os.ErrNotExist, 66, false)
+func Register(sentinel error, exitCode int, temporary bool) {
+	if sentinel == nil {
+		panic("goerr: Register called with nil sentinel")
+	}
+	comparable := reflect.TypeOf(sentinel).Comparable()
+
+	registryMu.Lock()
+	defer registryMu.Unlock()
+
+	for i := range registry {
+		if comparable && registry[i].sentinel == sentinel {
+			registry[i].exitCode, registry[i].temporary = exitCode, temporary
+			return
+		}
+	}
+	registry = append(registry, registration{
+		sentinel:  sentinel,
+		exitCode:  exitCode,
+		temporary: temporary,
+	})
+}
+
+// registered returns the registration of the first registered sentinel that
+// err matches. It uses Find rather than errors.Is, because Find visits a
+// bounded number of errors, so a cyclic chain cannot make it loop forever.
+func registered(err error) (registration, bool) {
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+
+	for _, r := range registry {
+		if Find(err, func(err error) bool { return matches(err, r.sentinel) }) != nil {
+			return r, true
+		}
+	}
+	return registration{}, false
+}
+
+// matches returns true when err is target, or reports itself as target by
+// its Is method.
+func matches(err, target error) bool {
+	if target != nil && reflect.TypeOf(target).Comparable() && err == target {
+		return true
+	}
+	tv, ok := err.(interface{ Is(error) bool })
+	return ok && tv.Is(target)
+}
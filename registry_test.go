@@ -0,0 +1,109 @@
+package goerr_test
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"testing"
+
+	"github.com/karrick/goerr"
+)
+
+var (
+	errRegisteredNotFound = errors.New("registered not found")
+	errRegisteredBusy     = errors.New("registered busy")
+)
+
+type uncomparableError struct{ codes []int }
+
+func (uncomparableError) Error() string { return "uncomparable" }
+
+// Is reports whether target is an uncomparableError with the same codes,
+// which is the only way an uncomparable sentinel can be matched.
+func (e uncomparableError) Is(target error) bool {
+	tv, ok := target.(uncomparableError)
+	return ok && slices.Equal(e.codes, tv.codes)
+}
+
+func TestRegister(t *testing.T) {
+	t.Cleanup(goerr.ResetRegistry())
+
+	goerr.Register(errRegisteredNotFound, 66, false)
+	goerr.Register(errRegisteredBusy, 0, false)
+	goerr.Register(errRegisteredBusy, 75, true) // replaces previous values
+
+	t.Run("wrapped sentinel", func(t *testing.T) {
+		err := goerr.Wrapf(fmt.Errorf("open config: %w", errRegisteredNotFound), "cannot configure")
+
+		if got, want := goerr.ExitCode(err), 66; got != want {
+			t.Errorf("GOT: %v; WANT: %v", got, want)
+		}
+		if got, want := goerr.Temporary(err), false; got != want {
+			t.Errorf("GOT: %v; WANT: %v", got, want)
+		}
+		if got, want := err.ExitCode(), 66; got != want {
+			t.Errorf("GOT: %v; WANT: %v", got, want)
+		}
+	})
+
+	t.Run("re-registered sentinel", func(t *testing.T) {
+		err := fmt.Errorf("lock: %w", errRegisteredBusy)
+
+		if got, want := goerr.ExitCode(err), 75; got != want {
+			t.Errorf("GOT: %v; WANT: %v", got, want)
+		}
+		if got, want := goerr.Temporary(err), true; got != want {
+			t.Errorf("GOT: %v; WANT: %v", got, want)
+		}
+
+		exitCode, temporary := goerr.Attributes(err)
+		if got, want := exitCode, 75; got != want {
+			t.Errorf("GOT: %v; WANT: %v", got, want)
+		}
+		if got, want := temporary, true; got != want {
+			t.Errorf("GOT: %v; WANT: %v", got, want)
+		}
+	})
+
+	t.Run("cyclic join", func(t *testing.T) {
+		c := &dummyMultiUnwrapper{}
+		c.errs = []error{c, c}
+
+		if got, want := goerr.ExitCode(c), 0; got != want {
+			t.Errorf("GOT: %v; WANT: %v", got, want)
+		}
+		if got, want := goerr.Temporary(c), false; got != want {
+			t.Errorf("GOT: %v; WANT: %v", got, want)
+		}
+	})
+
+	t.Run("uncomparable sentinel", func(t *testing.T) {
+		sentinel := uncomparableError{codes: []int{1}}
+		goerr.Register(sentinel, 65, false)
+		goerr.Register(sentinel, 65, false)
+
+		if got, want := goerr.ExitCode(goerr.Wrap(sentinel)), 65; got != want {
+			t.Errorf("GOT: %v; WANT: %v", got, want)
+		}
+		if got, want := goerr.ExitCode(errRegisteredNotFound), 66; got != want {
+			t.Errorf("GOT: %v; WANT: %v", got, want)
+		}
+	})
+
+	t.Run("nil sentinel", func(t *testing.T) {
+		defer func() {
+			if recover() == nil {
+				t.Errorf("GOT: no panic; WANT: panic")
+			}
+		}()
+		goerr.Register(nil, 1, false)
+	})
+
+	t.Run("own value takes precedence", func(t *testing.T) {
+		err := goerr.Wrap(errRegisteredNotFound).WithExitCode(13)
+
+		if got, want := goerr.ExitCode(err), 13; got != want {
+			t.Errorf("GOT: %v; WANT: %v", got, want)
+		}
+	})
+}
@@ -0,0 +1,98 @@
+package goerr
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Align specifies how PaddedLines positions each line within its width.
+type Align int
+
+const (
+	// AlignLeft places each line at the start of the width.
+	AlignLeft Align = iota
+
+	// AlignCenter places each line in the middle of the width, with any odd
+	// column of padding placed after the line.
+	AlignCenter
+
+	// AlignRight places each line at the end of the width.
+	AlignRight
+)
+
+// PaddedLines returns the lines of ErrorLines, each padded with spaces to
+// width columns and positioned according to align. Lines wider than width are
+// not changed. Lines that annotate the options line, such as those with
+// carets, are not padded themselves, but are indented by the same number of
+// columns as the options line, so they remain aligned with it.
+func (e Error) PaddedLines(width int, align Align) []string {
+	sections, order := e.sections()
+
+	var lines []string
+	for i, section := range sections {
+		var indent string
+		for j, line := range section {
+			if order[i] == SectionOptions && j > 0 {
+				lines = append(lines, indent+line)
+				continue
+			}
+			var left, right int
+			if pad := width - displayWidth(line); pad > 0 {
+				switch align {
+				case AlignCenter:
+					left = pad / 2
+				case AlignRight:
+					left = pad
+				}
+				right = pad - left
+			}
+			indent = strings.Repeat(" ", left)
+			lines = append(lines, indent+line+strings.Repeat(" ", right))
+		}
+	}
+	return lines
+}
+
+// RenderAll returns the lines of each non-nil error in errs, separated by
+// blank lines, followed by a summary line reporting the number of errors and
+// the highest exit code among them. Each *Error is rendered by its ErrorLines
+// method and has its exit code resolved by its ExitCode method, and each other
+// error is rendered by its Error method. A nil *Error is skipped like a nil
+// error. It returns nil when errs has no non-nil errors.
+func RenderAll(errs []error) []string {
+	var lines []string
+	var count, highest int
+
+	for _, err := range errs {
+		if err == nil {
+			continue
+		}
+		if ee, ok := err.(*Error); ok && ee == nil {
+			continue
+		}
+		if count > 0 {
+			lines = append(lines, "")
+		}
+		count++
+
+		if ee, ok := err.(*Error); ok && ee != nil {
+			lines = append(lines, ee.ErrorLines()...)
+		} else {
+			lines = append(lines, strings.Split(err.Error(), "\n")...)
+		}
+
+		if code := errorExitCode(err); code > highest {
+			highest = code
+		}
+	}
+
+	if count == 0 {
+		return nil
+	}
+
+	noun := "errors"
+	if count == 1 {
+		noun = "error"
+	}
+	return append(lines, "", fmt.Sprintf("%d %s, highest exit code %d", count, noun, highest))
+}
@@ -0,0 +1,105 @@
+package goerr_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/karrick/goerr"
+)
+
+func TestRenderAll(t *testing.T) {
+	t.Run("sans errors", func(t *testing.T) {
+		if got, want := len(goerr.RenderAll([]error{nil})), 0; got != want {
+			t.Errorf("GOT: %v; WANT: %v", got, want)
+		}
+	})
+
+	t.Run("nil Error", func(t *testing.T) {
+		var ee *goerr.Error
+		lines := goerr.RenderAll([]error{ee, errors.New("plain error")})
+
+		want := []string{
+			"plain error",
+			"",
+			"1 error, highest exit code 0",
+		}
+		if got, want := len(lines), len(want); got != want {
+			t.Fatalf("GOT: %q; WANT: %v lines", lines, want)
+		}
+		for i := range want {
+			if got, want := lines[i], want[i]; got != want {
+				t.Errorf("GOT: %q; WANT: %q", got, want)
+			}
+		}
+	})
+
+	t.Run("single error", func(t *testing.T) {
+		lines := goerr.RenderAll([]error{errors.New("plain error")})
+
+		if got, want := len(lines), 3; got != want {
+			t.Fatalf("GOT: %v; WANT: %v", got, want)
+		}
+		if got, want := lines[2], "1 error, highest exit code 0"; got != want {
+			t.Errorf("GOT: %q; WANT: %q", got, want)
+		}
+	})
+
+	t.Run("wrapped exit coder", func(t *testing.T) {
+		lines := goerr.RenderAll([]error{goerr.Wrapf(&dummyExitCoder{code: 42}, "ctx")})
+
+		if got, want := lines[len(lines)-1], "1 error, highest exit code 42"; got != want {
+			t.Errorf("GOT: %q; WANT: %q", got, want)
+		}
+	})
+
+	t.Run("mixed errors", func(t *testing.T) {
+		lines := goerr.RenderAll([]error{
+			goerr.New("first error").WithExitCode(2).WithLineAfterOptions("after"),
+			nil,
+			errors.New("plain error"),
+			goerr.New("third error").WithExitCode(13),
+		})
+
+		want := []string{
+			"first error",
+			"after",
+			"",
+			"plain error",
+			"",
+			"third error",
+			"",
+			"3 errors, highest exit code 13",
+		}
+
+		if got, want := len(lines), len(want); got != want {
+			t.Fatalf("GOT: %q; WANT: %v lines", lines, want)
+		}
+		for i := range want {
+			if got, want := lines[i], want[i]; got != want {
+				t.Errorf("GOT: %q; WANT: %q", got, want)
+			}
+		}
+	})
+}
+
+func TestPaddedLines(t *testing.T) {
+	ee := goerr.New("cannot open file").
+		WithOptions([]string{"cat", "missing.txt"}).
+		WithOptionComment(1, "no such file")
+
+	lines := ee.PaddedLines(40, goerr.AlignCenter)
+
+	want := []string{
+		"            cannot open file            ",
+		"            cat missing.txt             ",
+		"                ^~~~~~~~~~~ no such file",
+	}
+	if got, want := len(lines), len(want); got != want {
+		t.Fatalf("GOT: %v; WANT: %v", got, want)
+	}
+	for i := range want {
+		if got, want := lines[i], want[i]; got != want {
+			t.Errorf("GOT: %q; WANT: %q", got, want)
+		}
+	}
+}
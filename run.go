@@ -0,0 +1,29 @@
+package goerr
+
+import (
+	"fmt"
+	"io"
+	"os"
+)
+
+// RunWriter is where Run displays the error returned by its function. It may
+// be replaced, such as by tests.
+var RunWriter io.Writer = os.Stderr
+
+// Run invokes fn and returns the exit code to be given to os.Exit. When fn
+// returns nil, or a nil *Error, it returns 0. Otherwise it displays every line of the error on
+// RunWriter, and returns the exit code of the error, or 1 when that is 0, as
+// ShouldExit does.
+//
+//	func main() {
+//	    os.Exit(goerr.Run(run))
+//	}
+func Run(fn func() error) int {
+	err := fn()
+	code, ok := ShouldExit(err)
+	if !ok {
+		return 0
+	}
+	fmt.Fprintln(RunWriter, err.Error())
+	return code
+}
@@ -0,0 +1,91 @@
+package goerr_test
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"testing"
+
+	"github.com/karrick/goerr"
+)
+
+func TestRun(t *testing.T) {
+	defer func(w io.Writer) { goerr.RunWriter = w }(goerr.RunWriter)
+
+	run := func(err error) (int, string) {
+		var buf bytes.Buffer
+		goerr.RunWriter = &buf
+		code := goerr.Run(func() error { return err })
+		return code, buf.String()
+	}
+
+	t.Run("err nil", func(t *testing.T) {
+		code, output := run(nil)
+
+		if got, want := code, 0; got != want {
+			t.Errorf("GOT: %v; WANT: %v", got, want)
+		}
+		if got, want := output, ""; got != want {
+			t.Errorf("GOT: %q; WANT: %q", got, want)
+		}
+	})
+
+	t.Run("nil Error", func(t *testing.T) {
+		code, output := run(goerr.Wrapf(nil, "cannot configure"))
+
+		if got, want := code, 0; got != want {
+			t.Errorf("GOT: %v; WANT: %v", got, want)
+		}
+		if got, want := output, ""; got != want {
+			t.Errorf("GOT: %q; WANT: %q", got, want)
+		}
+	})
+
+	t.Run("err sans exit code", func(t *testing.T) {
+		code, output := run(errors.New("some error"))
+
+		if got, want := code, 1; got != want {
+			t.Errorf("GOT: %v; WANT: %v", got, want)
+		}
+		if got, want := output, "some error\n"; got != want {
+			t.Errorf("GOT: %q; WANT: %q", got, want)
+		}
+	})
+
+	t.Run("err with exit code", func(t *testing.T) {
+		code, output := run(goerr.New("cannot configure").
+			WithExitCode(5).
+			WithLineAfterOptions("see usage"))
+
+		if got, want := code, 5; got != want {
+			t.Errorf("GOT: %v; WANT: %v", got, want)
+		}
+		if got, want := output, "cannot configure\nsee usage\n"; got != want {
+			t.Errorf("GOT: %q; WANT: %q", got, want)
+		}
+	})
+
+	t.Run("exit code override", func(t *testing.T) {
+		defer func(fn func(error) (int, bool)) { goerr.ExitCodeOverride = fn }(goerr.ExitCodeOverride)
+		goerr.ExitCodeOverride = func(err error) (int, bool) {
+			if errors.Is(err, io.EOF) {
+				return 99, true
+			}
+			return 0, false
+		}
+
+		code, _ := run(goerr.Wrap(io.EOF).WithExitCode(42))
+
+		if got, want := code, 99; got != want {
+			t.Errorf("GOT: %v; WANT: %v", got, want)
+		}
+	})
+
+	t.Run("wrapped exit coder", func(t *testing.T) {
+		code, _ := run(goerr.Wrapf(&dummyExitCoder{code: 5}, "ctx"))
+
+		if got, want := code, 5; got != want {
+			t.Errorf("GOT: %v; WANT: %v", got, want)
+		}
+	})
+}
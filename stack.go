@@ -0,0 +1,71 @@
+package goerr
+
+import "reflect"
+
+// StackTracer is implemented by errors that can report the program counters
+// of the stack at the point where they were created.
+type StackTracer interface {
+	StackTrace() []uintptr
+}
+
+// StackTrace returns the stack trace of the first error in the chain of err
+// that provides one, following Unwrap until an error does not implement
+// Unwrap. When an error unwraps to multiple errors, the first one is
+// followed.
+//
+// In addition to StackTracer, errors whose StackTrace method returns a slice
+// of uintptr-based values are recognized, such as those created by
+// github.com/pkg/errors, without importing that package.
+func StackTrace(err error) ([]uintptr, bool) {
+	for depth := 0; err != nil && depth < maxUnwrapDepth; depth++ {
+		if pcs, ok := stackTrace(err); ok {
+			return pcs, true
+		}
+		switch tv := err.(type) {
+		case *Error:
+			if tv == nil {
+				return nil, false
+			}
+			err = tv.err
+		case unwrapper:
+			err = tv.Unwrap()
+		case multiUnwrapper:
+			errs := tv.Unwrap()
+			if len(errs) == 0 {
+				return nil, false
+			}
+			err = errs[0]
+		default:
+			return nil, false
+		}
+	}
+	return nil, false
+}
+
+// stackTrace returns the program counters reported by the StackTrace method
+// of err, when err has such a method.
+func stackTrace(err error) ([]uintptr, bool) {
+	if st, ok := err.(StackTracer); ok {
+		return st.StackTrace(), true
+	}
+
+	m := reflect.ValueOf(err).MethodByName("StackTrace")
+	if !m.IsValid() {
+		return nil, false
+	}
+	mt := m.Type()
+	if mt.NumIn() != 0 || mt.NumOut() != 1 {
+		return nil, false
+	}
+	rt := mt.Out(0)
+	if rt.Kind() != reflect.Slice || rt.Elem().Kind() != reflect.Uintptr {
+		return nil, false
+	}
+
+	rv := m.Call(nil)[0]
+	pcs := make([]uintptr, rv.Len())
+	for i := range pcs {
+		pcs[i] = uintptr(rv.Index(i).Uint())
+	}
+	return pcs, true
+}
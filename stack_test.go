@@ -0,0 +1,76 @@
+package goerr_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/karrick/goerr"
+)
+
+type dummyStackTracer struct{ pcs []uintptr }
+
+func (dst dummyStackTracer) Error() string { return "has stack trace" }
+
+func (dst dummyStackTracer) StackTrace() []uintptr { return dst.pcs }
+
+// dummyFrame and dummyStack mirror the Frame and StackTrace types from
+// github.com/pkg/errors.
+type dummyFrame uintptr
+
+type dummyStack []dummyFrame
+
+type dummyPkgErrorsStackTracer struct{ stack dummyStack }
+
+func (dst dummyPkgErrorsStackTracer) Error() string { return "has pkg/errors stack trace" }
+
+func (dst dummyPkgErrorsStackTracer) StackTrace() dummyStack { return dst.stack }
+
+func TestStackTrace(t *testing.T) {
+	t.Run("err nil", func(t *testing.T) {
+		_, ok := goerr.StackTrace(nil)
+
+		if got, want := ok, false; got != want {
+			t.Errorf("GOT: %v; WANT: %v", got, want)
+		}
+	})
+
+	t.Run("err default", func(t *testing.T) {
+		_, ok := goerr.StackTrace(goerr.Wrap(errors.New("no stack")))
+
+		if got, want := ok, false; got != want {
+			t.Errorf("GOT: %v; WANT: %v", got, want)
+		}
+	})
+
+	t.Run("err StackTracer in chain", func(t *testing.T) {
+		err := goerr.Wrapf(&dummyUnwrapper{err: dummyStackTracer{pcs: []uintptr{1, 2, 3}}}, "outer")
+
+		pcs, ok := goerr.StackTrace(err)
+
+		if got, want := ok, true; got != want {
+			t.Fatalf("GOT: %v; WANT: %v", got, want)
+		}
+		if got, want := len(pcs), 3; got != want {
+			t.Fatalf("GOT: %v; WANT: %v", got, want)
+		}
+		if got, want := pcs[2], uintptr(3); got != want {
+			t.Errorf("GOT: %v; WANT: %v", got, want)
+		}
+	})
+
+	t.Run("err pkg/errors stack tracer in chain", func(t *testing.T) {
+		err := goerr.Wrap(dummyPkgErrorsStackTracer{stack: dummyStack{4, 5}})
+
+		pcs, ok := goerr.StackTrace(err)
+
+		if got, want := ok, true; got != want {
+			t.Fatalf("GOT: %v; WANT: %v", got, want)
+		}
+		if got, want := len(pcs), 2; got != want {
+			t.Fatalf("GOT: %v; WANT: %v", got, want)
+		}
+		if got, want := pcs[0], uintptr(4); got != want {
+			t.Errorf("GOT: %v; WANT: %v", got, want)
+		}
+	})
+}
@@ -0,0 +1,34 @@
+package goerr
+
+// TemplateOption configures each Error created by the function returned by
+// Template.
+type TemplateOption func(*Error)
+
+// TemplateExitCode returns a TemplateOption that stores code as the exit code
+// of each Error created from the template.
+func TemplateExitCode(code int) TemplateOption {
+	return func(e *Error) { e.WithExitCode(code) }
+}
+
+// TemplateTemporary returns a TemplateOption that stores temporary as the
+// temporary value of each Error created from the template.
+func TemplateTemporary(temporary bool) TemplateOption {
+	return func(e *Error) { e.WithTemporary(temporary) }
+}
+
+// Template returns a function that creates a new Error each time it is
+// invoked, whose message is formatted from format and the arguments given to
+// the function, and to which each of opts is applied. It allows errors that
+// only differ by a value to share their other attributes.
+//
+//	errNotFound := goerr.Template("cannot find %q", goerr.TemplateExitCode(66))
+//	return errNotFound(name)
+func Template(format string, opts ...TemplateOption) func(a ...any) *Error {
+	return func(a ...any) *Error {
+		e := New(format, a...)
+		for _, opt := range opts {
+			opt(e)
+		}
+		return e
+	}
+}
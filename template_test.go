@@ -0,0 +1,32 @@
+package goerr_test
+
+import (
+	"testing"
+
+	"github.com/karrick/goerr"
+)
+
+func TestTemplate(t *testing.T) {
+	notFound := goerr.Template("cannot find %q", goerr.TemplateExitCode(7), goerr.TemplateTemporary(true))
+
+	first := notFound("alpha")
+	second := notFound("beta")
+
+	if got, want := first.Error(), `cannot find "alpha"`; got != want {
+		t.Errorf("GOT: %q; WANT: %q", got, want)
+	}
+	if got, want := second.Error(), `cannot find "beta"`; got != want {
+		t.Errorf("GOT: %q; WANT: %q", got, want)
+	}
+	for _, ee := range []*goerr.Error{first, second} {
+		if got, want := ee.ExitCode(), 7; got != want {
+			t.Errorf("GOT: %v; WANT: %v", got, want)
+		}
+		if got, want := ee.Temporary(), true; got != want {
+			t.Errorf("GOT: %v; WANT: %v", got, want)
+		}
+	}
+	if first == second {
+		t.Errorf("GOT: same instance; WANT: distinct instances")
+	}
+}
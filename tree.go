@@ -0,0 +1,53 @@
+package goerr
+
+// ErrorNode is a structured representation of an error, suitable for
+// rendering by user interfaces that display errors as expandable trees
+// rather than lines of text.
+type ErrorNode struct {
+	// Message is the message of the error, without the message of the error
+	// it wraps.
+	Message string
+
+	// BeforeMessage holds the lines displayed before the message.
+	BeforeMessage []string
+
+	// BetweenMessageAndOptions holds the lines displayed between the message
+	// and the option lines.
+	BetweenMessageAndOptions []string
+
+	// OptionLines holds the options line followed by a line for each option
+	// comment.
+	OptionLines []string
+
+	// AfterOptions holds the lines displayed after the option lines.
+	AfterOptions []string
+
+	// Cause is the node for the wrapped error, or nil when the error does not
+	// wrap an error.
+	Cause *ErrorNode
+}
+
+// Tree returns a structured representation of this instance and the chain of
+// errors it wraps. Each wrapped *Error becomes a node of its own, while any
+// other wrapped error becomes a leaf node whose Message is its Error result.
+func (e Error) Tree() *ErrorNode {
+	node := &ErrorNode{
+		Message:                  e.ownMessage(),
+		BeforeMessage:            append([]string(nil), e.beforeMessage...),
+		BetweenMessageAndOptions: append([]string(nil), e.betweenMessageAndOptions...),
+		OptionLines:              e.optionLines(),
+		AfterOptions:             append([]string(nil), e.afterOptions...),
+	}
+
+	switch tv := e.err.(type) {
+	case nil:
+	case *Error:
+		if tv != nil {
+			node.Cause = tv.Tree()
+		}
+	default:
+		node.Cause = &ErrorNode{Message: tv.Error()}
+	}
+
+	return node
+}
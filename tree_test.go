@@ -0,0 +1,73 @@
+package goerr_test
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/karrick/goerr"
+)
+
+func TestTree(t *testing.T) {
+	t.Run("sans wrapped error", func(t *testing.T) {
+		node := goerr.New("some error message").Tree()
+
+		if got, want := node.Message, "some error message"; got != want {
+			t.Errorf("GOT: %q; WANT: %q", got, want)
+		}
+		if got, want := node.Cause, (*goerr.ErrorNode)(nil); got != want {
+			t.Errorf("GOT: %v; WANT: %v", got, want)
+		}
+	})
+
+	t.Run("two levels", func(t *testing.T) {
+		inner := goerr.Wrapf(fmt.Errorf("foo: %v", "bar"), "cannot open").
+			WithLineAfterOptions("inner after")
+
+		node := goerr.Wrapf(inner, "cannot configure").
+			WithLineBeforeMessage("before").
+			WithOptions([]string{"zero", "one"}).
+			WithOptionComment(1, "for this option").
+			Tree()
+
+		if got, want := node.Message, "cannot configure"; got != want {
+			t.Errorf("GOT: %q; WANT: %q", got, want)
+		}
+		if got, want := len(node.BeforeMessage), 1; got != want {
+			t.Fatalf("GOT: %v; WANT: %v", got, want)
+		}
+		if got, want := node.BeforeMessage[0], "before"; got != want {
+			t.Errorf("GOT: %q; WANT: %q", got, want)
+		}
+		if got, want := len(node.OptionLines), 2; got != want {
+			t.Fatalf("GOT: %v; WANT: %v", got, want)
+		}
+		if got, want := node.OptionLines[1], "     ^~~ for this option"; got != want {
+			t.Errorf("GOT: %q; WANT: %q", got, want)
+		}
+
+		child := node.Cause
+		if child == nil {
+			t.Fatalf("GOT: %v; WANT: non-nil", child)
+		}
+		if got, want := child.Message, "cannot open"; got != want {
+			t.Errorf("GOT: %q; WANT: %q", got, want)
+		}
+		if got, want := len(child.AfterOptions), 1; got != want {
+			t.Fatalf("GOT: %v; WANT: %v", got, want)
+		}
+		if got, want := child.AfterOptions[0], "inner after"; got != want {
+			t.Errorf("GOT: %q; WANT: %q", got, want)
+		}
+
+		leaf := child.Cause
+		if leaf == nil {
+			t.Fatalf("GOT: %v; WANT: non-nil", leaf)
+		}
+		if got, want := leaf.Message, "foo: bar"; got != want {
+			t.Errorf("GOT: %q; WANT: %q", got, want)
+		}
+		if got, want := leaf.Cause, (*goerr.ErrorNode)(nil); got != want {
+			t.Errorf("GOT: %v; WANT: %v", got, want)
+		}
+	})
+}
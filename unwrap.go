@@ -1,32 +1,422 @@
 package goerr
 
+import (
+	"errors"
+	"reflect"
+	"strings"
+)
+
 type exitCoder interface{ ExitCode() int }
 
+// exitStatuser is implemented by syscall.WaitStatus, among others.
+type exitStatuser interface{ ExitStatus() int }
+
+// syser is implemented by *os.ProcessState, among others, whose Sys method
+// may return a value that implements exitStatuser.
+type syser interface{ Sys() any }
+
 type temporaryer interface{ Temporary() bool }
 
+type helpURLer interface{ HelpURL() string }
+
+type timeouter interface{ Timeout() bool }
+
 type unwrapper interface{ Unwrap() error }
 
+type multiUnwrapper interface{ Unwrap() []error }
+
+// maxUnwrapDepth limits how many errors are visited when following a chain of
+// wrapped errors, so a chain that cycles back on itself cannot cause an
+// infinite loop.
+const maxUnwrapDepth = 100
+
+// ExitCodeOverride, when not nil, is invoked by ExitCode before examining
+// err. When it returns true, ExitCode returns the code it returns.
+var ExitCodeOverride func(err error) (int, bool)
+
+// AnyTemporary returns true when any error in the chain of err reports that it
+// is temporary, visiting every error wrapped by errors that wrap multiple
+// errors. This differs from Temporary, which returns the first value found,
+// so an outer error that is marked not temporary hides a temporary error it
+// wraps.
+func AnyTemporary(err error) bool {
+	var found bool
+	walk(err, func(err error) bool {
+		switch tv := err.(type) {
+		case *Error:
+			found = tv.isTemporarySet && tv.ownTemporary()
+		case temporaryer:
+			found = tv.Temporary()
+		}
+		return !found
+	})
+	return found
+}
+
+// Attributes returns the same exit code and temporary values as ExitCode and
+// Temporary, but visits the chain of err only once to find both.
+func Attributes(err error) (exitCode int, temporary bool) {
+	var exitCodeFound, temporaryFound bool
+
+	if ExitCodeOverride != nil {
+		exitCode, exitCodeFound = ExitCodeOverride(err)
+	}
+
+	root := err
+
+chain:
+	for depth := 0; depth < maxUnwrapDepth; depth++ {
+		switch tv := err.(type) {
+		case nil:
+			break chain
+		case *Error:
+			if tv != nil {
+				if !exitCodeFound {
+					exitCode, exitCodeFound = tv.ownExitCode()
+				}
+				if !temporaryFound {
+					temporary, temporaryFound = tv.ownTemporary(), tv.isTemporarySet
+				}
+			}
+			break chain
+		}
+
+		if code, ok := ownExitCode(err); ok && !exitCodeFound {
+			exitCode, exitCodeFound = code, true
+		}
+		if tv, ok := err.(temporaryer); ok && !temporaryFound {
+			temporary, temporaryFound = tv.Temporary(), true
+		}
+		if exitCodeFound && temporaryFound {
+			return exitCode, temporary
+		}
+
+		tv, ok := err.(unwrapper)
+		if !ok {
+			break
+		}
+		err = tv.Unwrap()
+	}
+
+	if !exitCodeFound || !temporaryFound {
+		if r, ok := registered(root); ok {
+			if !exitCodeFound {
+				exitCode = r.exitCode
+			}
+			if !temporaryFound {
+				temporary = r.temporary
+			}
+		}
+	}
+
+	return exitCode, temporary
+}
+
+// Cause returns the error directly wrapped by err, or nil when err does not
+// wrap an error. When err wraps multiple errors, the first one is returned.
+func Cause(err error) error {
+	switch tv := err.(type) {
+	case *Error:
+		if tv == nil {
+			return nil
+		}
+		return tv.err
+	case unwrapper:
+		return tv.Unwrap()
+	case multiUnwrapper:
+		if errs := tv.Unwrap(); len(errs) > 0 {
+			return errs[0]
+		}
+	}
+	return nil
+}
+
 // ExitCode returns the result of invoking the ExitCode method for err or the
 // first wrapped error recursing until an error does not implement Unwrap or
-// the err is nil.
+// the err is nil. Errors with an ExitStatus method, or with a Sys method that
+// returns a value with an ExitStatus method, are also recognized. When
+// ExitCodeOverride is not nil, it is consulted first. When no error in the
+// chain provides an exit code, the exit code registered for a matching
+// sentinel error is returned.
 func ExitCode(err error) int {
-	exitCode, _ := unwrapExitCode(err)
+	if ExitCodeOverride != nil {
+		if exitCode, ok := ExitCodeOverride(err); ok {
+			return exitCode
+		}
+	}
+	exitCode, ok := unwrapExitCode(err)
+	if !ok {
+		if r, ok := registered(err); ok {
+			return r.exitCode
+		}
+	}
 	return exitCode
 }
 
+// ExitCodeByPattern returns the code mapped to by the key of patterns that is
+// contained in the message of err, which allows assigning exit codes to
+// errors that do not provide one. When more than one key is contained in the
+// message, the longest key is used, and keys of equal length are compared
+// lexicographically, so the result does not depend on map iteration order.
+// When no key is contained in the message, it returns ExitCode(err).
+func ExitCodeByPattern(err error, patterns map[string]int) int {
+	if err == nil {
+		return 0
+	}
+	message := err.Error()
+
+	var match string
+	var found bool
+	for pattern := range patterns {
+		if !strings.Contains(message, pattern) {
+			continue
+		}
+		if !found || len(pattern) > len(match) || (len(pattern) == len(match) && pattern < match) {
+			match, found = pattern, true
+		}
+	}
+	if found {
+		return patterns[match]
+	}
+	return ExitCode(err)
+}
+
+// Find returns the first error in the chain of err for which pred returns
+// true, visiting every error wrapped by errors that wrap multiple errors in
+// depth-first order, or nil when there is no such error.
+func Find(err error, pred func(error) bool) error {
+	var found error
+	walk(err, func(err error) bool {
+		if pred(err) {
+			found = err
+			return false
+		}
+		return true
+	})
+	return found
+}
+
+// InnermostExitCode returns the exit code of the deepest error in the chain of
+// err that provides one, or 0 when none does. It is useful when the exit code
+// of the root cause should take precedence over any set by wrapping errors.
+func InnermostExitCode(err error) int {
+	var exitCode int
+	walk(err, func(err error) bool {
+		if code, ok := ownExitCode(err); ok {
+			exitCode = code
+		}
+		return true
+	})
+	return exitCode
+}
+
+// IsAny returns true when errors.Is reports that err matches any of targets,
+// which simplifies checking a result against several sentinel errors, for
+// instance when err joins multiple errors.
+func IsAny(err error, targets ...error) bool {
+	for _, target := range targets {
+		if errors.Is(err, target) {
+			return true
+		}
+	}
+	return false
+}
+
+// IsWarning returns true when err or an error in its chain is an *Error
+// marked as a warning by WithWarning.
+func IsWarning(err error) bool {
+	return Find(err, func(err error) bool {
+		tv, ok := err.(*Error)
+		return ok && tv.warning
+	}) != nil
+}
+
+// OutermostExitCode returns the exit code of the first error in the chain of
+// err that provides one, scanning from the outermost error inward, or 0 when
+// none does. Unlike ExitCode, it continues past an *Error that does not have
+// an exit code set.
+func OutermostExitCode(err error) int {
+	var exitCode int
+	walk(err, func(err error) bool {
+		code, ok := ownExitCode(err)
+		if ok {
+			exitCode = code
+		}
+		return !ok
+	})
+	return exitCode
+}
+
+// ReduceExitCodes returns the result of folding every exit code provided by
+// the errors in the chain of err, from the outermost inward, using reducer,
+// starting with initial. For instance, passing a reducer that returns the
+// greater of its arguments returns the highest exit code in the chain.
+func ReduceExitCodes(err error, reducer func(acc, code int) int, initial int) int {
+	acc := initial
+	walk(err, func(err error) bool {
+		if code, ok := ownExitCode(err); ok {
+			acc = reducer(acc, code)
+		}
+		return true
+	})
+	return acc
+}
+
+// ShouldExit returns false when err is nil, including a nil *Error such as
+// returned by Wrapf when its error is nil; otherwise it returns true and the
+// exit code of err, or 1 when the exit code of err is 0, so that a non-nil
+// error always results in a nonzero exit code. When err is an *Error, its exit
+// code is resolved by its ExitCode method, so an *Error without an exit code
+// set uses the exit code of the error it wraps.
+func ShouldExit(err error) (int, bool) {
+	if err == nil {
+		return 0, false
+	}
+	if tv, ok := err.(*Error); ok && tv == nil {
+		return 0, false
+	}
+	if code := errorExitCode(err); code != 0 {
+		return code, true
+	}
+	return 1, true
+}
+
 // Temporary returns the result of invoking the Temporary method for err or
 // the first wrapped error recursing until an error does not implement Unwrap
-// or the err is nil.
+// or the err is nil. When no error in the chain provides a temporary value,
+// the temporary value registered for a matching sentinel error is returned.
 func Temporary(err error) bool {
-	isTemporary, _ := unwrapTemporary(err)
+	isTemporary, ok := unwrapTemporary(err)
+	if !ok {
+		if r, ok := registered(err); ok {
+			return r.temporary
+		}
+	}
 	return isTemporary
 }
 
+// Timeout returns the result of invoking the Timeout method for err or the
+// first wrapped error that implements it, recursing until an error does not
+// implement Unwrap or the err is nil, or false when none does. Errors of the
+// net package implement Timeout, along with the deprecated Temporary method
+// that Temporary consults; unlike Temporary, a timeout reports why an
+// operation failed rather than whether retrying it may succeed, and an *Error
+// never provides a Timeout value of its own.
+func Timeout(err error) bool {
+	for depth := 0; depth < maxUnwrapDepth; depth++ {
+		switch tv := err.(type) {
+		case nil:
+			return false
+		case *Error:
+			if tv == nil {
+				return false
+			}
+			err = tv.err
+		case timeouter:
+			return tv.Timeout()
+		case unwrapper:
+			err = tv.Unwrap()
+		default:
+			return false
+		}
+	}
+	// When the chain is too deep or cycles, return the default value.
+	return false
+}
+
+// RootCause returns the innermost error in the chain of err, following Unwrap
+// until an error does not implement Unwrap. When an error unwraps to multiple
+// errors, the first one is followed. It returns nil when err is nil. When the
+// chain is too deep or cycles, the last error visited is returned.
+func RootCause(err error) error {
+	for depth := 0; depth < maxUnwrapDepth; depth++ {
+		switch tv := err.(type) {
+		case nil:
+			return nil
+		case *Error:
+			if tv == nil {
+				return nil
+			}
+			if tv.err == nil {
+				return tv
+			}
+			err = tv.err
+		case unwrapper:
+			next := tv.Unwrap()
+			if next == nil {
+				return err
+			}
+			err = next
+		case multiUnwrapper:
+			errs := tv.Unwrap()
+			if len(errs) == 0 || errs[0] == nil {
+				return err
+			}
+			err = errs[0]
+		default:
+			return err
+		}
+	}
+	return err
+}
+
+// errorExitCode returns the exit code of err, resolving an *Error by its
+// ExitCode method so that one without an exit code set defers to the error it
+// wraps, and any other error by ExitCode. Like ExitCode, it consults
+// ExitCodeOverride first when it is not nil.
+func errorExitCode(err error) int {
+	if ExitCodeOverride != nil {
+		if exitCode, ok := ExitCodeOverride(err); ok {
+			return exitCode
+		}
+	}
+	if tv, ok := err.(*Error); ok && tv != nil {
+		return tv.ExitCode()
+	}
+	return ExitCode(err)
+}
+
+// errorTemporary returns the temporary value of err, resolving an *Error by
+// its Temporary method so that one without a temporary value set defers to the
+// error it wraps, and any other error by Temporary.
+func errorTemporary(err error) bool {
+	if tv, ok := err.(*Error); ok && tv != nil {
+		return tv.Temporary()
+	}
+	return Temporary(err)
+}
+
+// resolveExitCode returns the exit code of err the way the ExitCode method
+// of Error does: an *Error that does not have an exit code set defers to the
+// error it wraps rather than ending the search.
+func resolveExitCode(err error) (int, bool) {
+	if tv, ok := err.(*Error); ok && tv != nil {
+		if code, ok := tv.ownExitCode(); ok {
+			return code, true
+		}
+		err = tv.err
+	}
+	return unwrapExitCode(err)
+}
+
+// resolveTemporary returns the temporary value of err the way the Temporary
+// method of Error does: an *Error that does not have a temporary value set
+// defers to the error it wraps rather than ending the search.
+func resolveTemporary(err error) (bool, bool) {
+	if tv, ok := err.(*Error); ok && tv != nil {
+		if tv.isTemporarySet {
+			return tv.ownTemporary(), true
+		}
+		err = tv.err
+	}
+	return unwrapTemporary(err)
+}
+
 // unwrapExitCode returns the exit code from err or the first unwrapped error
-// that implements the ExitCode method. If err and none of its unwrapped
-// values implement ExitCode, this returns 0.
+// that provides one, as described by ownExitCode. If err and none of its
+// unwrapped values provide an exit code, this returns 0.
 func unwrapExitCode(err error) (int, bool) {
-	for {
+	for depth := 0; depth < maxUnwrapDepth; depth++ {
 		switch tv := err.(type) {
 		case nil:
 			// When nil, return the default value.
@@ -36,25 +426,29 @@ func unwrapExitCode(err error) (int, bool) {
 				// When nil, return the default value.
 				return 0, false
 			}
-			return tv.exitCode, tv.isExitCodeSet
-		case exitCoder:
-			// When err implements ExitCode then return it.
-			return tv.ExitCode(), true
-		case unwrapper:
-			// When error implements Unwrap, then recurse.
-			err = tv.Unwrap()
-		default:
+			return tv.ownExitCode()
+		}
+		if code, ok := ownExitCode(err); ok {
+			// When err provides an exit code then return it.
+			return code, true
+		}
+		tv, ok := err.(unwrapper)
+		if !ok {
 			// When none of the above, return the default value.
 			return 0, false
 		}
+		// When error implements Unwrap, then recurse.
+		err = tv.Unwrap()
 	}
+	// When the chain is too deep or cycles, return the default value.
+	return 0, false
 }
 
 // unwrapTempoary returns whether err is temporary, or the result of invoking
 // Temporary method of the first unwrapped error it unwraps.  If err and none
 // of its unwrapped values implement Temporary, this returns false.
 func unwrapTemporary(err error) (bool, bool) {
-	for {
+	for depth := 0; depth < maxUnwrapDepth; depth++ {
 		switch tv := err.(type) {
 		case nil:
 			// When nil, return the default value.
@@ -64,7 +458,7 @@ func unwrapTemporary(err error) (bool, bool) {
 				// When nil, return the default value.
 				return false, false
 			}
-			return tv.temporary, tv.isTemporarySet
+			return tv.ownTemporary(), tv.isTemporarySet
 		case temporaryer:
 			// When err implements ExitCode then return it.
 			return tv.Temporary(), true
@@ -76,4 +470,87 @@ func unwrapTemporary(err error) (bool, bool) {
 			return false, false
 		}
 	}
+	// When the chain is too deep or cycles, return the default value.
+	return false, false
+}
+
+// ownExitCode returns the exit code provided by err itself, without examining
+// the errors it wraps. In addition to the ExitCode method, an ExitStatus
+// method is recognized, as is a Sys method that returns a value with an
+// ExitStatus method, such as the syscall.WaitStatus of *os.ProcessState.
+func ownExitCode(err error) (int, bool) {
+	switch tv := err.(type) {
+	case *Error:
+		return tv.ownExitCode()
+	case exitCoder:
+		return tv.ExitCode(), true
+	case exitStatuser:
+		return tv.ExitStatus(), true
+	case syser:
+		if ws, ok := tv.Sys().(exitStatuser); ok {
+			return ws.ExitStatus(), true
+		}
+	}
+	return 0, false
+}
+
+// walk invokes fn for err and each error in its chain, in depth-first order,
+// visiting every error wrapped by errors that wrap multiple errors. It stops
+// when fn returns false. Each comparable error is visited at most once, and
+// at most maxWalkNodes errors are visited in total, so neither an error that
+// wraps the same error more than once nor a cycle makes the walk take
+// exponential or unbounded time.
+func walk(err error, fn func(error) bool) bool {
+	w := &walker{fn: fn, budget: maxWalkNodes}
+	return w.walk(err, 0)
+}
+
+// maxWalkNodes limits how many errors walk visits.
+const maxWalkNodes = 10 * maxUnwrapDepth
+
+// walker holds the state shared by the recursive invocations of walk.
+type walker struct {
+	fn      func(error) bool
+	visited map[error]struct{}
+	budget  int
+}
+
+func (w *walker) walk(err error, depth int) bool {
+	for ; depth < maxUnwrapDepth; depth++ {
+		if err == nil || w.budget == 0 {
+			return true
+		}
+		if tv, ok := err.(*Error); ok && tv == nil {
+			return true
+		}
+		w.budget--
+		if reflect.TypeOf(err).Comparable() {
+			if _, ok := w.visited[err]; ok {
+				return true
+			}
+			if w.visited == nil {
+				w.visited = make(map[error]struct{})
+			}
+			w.visited[err] = struct{}{}
+		}
+		if !w.fn(err) {
+			return false
+		}
+		switch tv := err.(type) {
+		case *Error:
+			err = tv.err
+		case unwrapper:
+			err = tv.Unwrap()
+		case multiUnwrapper:
+			for _, err := range tv.Unwrap() {
+				if !w.walk(err, depth+1) {
+					return false
+				}
+			}
+			return true
+		default:
+			return true
+		}
+	}
+	return true
 }
@@ -16,6 +16,20 @@ func (dec dummyExitCoder) Error() string {
 
 func (dec dummyExitCoder) ExitCode() int { return dec.code }
 
+type dummyExitStatuser struct{ status int }
+
+func (des dummyExitStatuser) Error() string {
+	return fmt.Sprintf("returns exit status: %d", des.status)
+}
+
+func (des dummyExitStatuser) ExitStatus() int { return des.status }
+
+type dummySyser struct{ sys any }
+
+func (ds dummySyser) Error() string { return "returns sys" }
+
+func (ds dummySyser) Sys() any { return ds.sys }
+
 type dummyTemporaryer struct{ temporary bool }
 
 func (dec dummyTemporaryer) Error() string {
@@ -24,6 +38,20 @@ func (dec dummyTemporaryer) Error() string {
 
 func (dec dummyTemporaryer) Temporary() bool { return dec.temporary }
 
+type dummyTimeouter struct{ timeout bool }
+
+func (dt dummyTimeouter) Error() string {
+	return fmt.Sprintf("returns timeout: %t", dt.timeout)
+}
+
+func (dt dummyTimeouter) Timeout() bool { return dt.timeout }
+
+type dummyMultiUnwrapper struct{ errs []error }
+
+func (dmu dummyMultiUnwrapper) Error() string { return "unwraps multiple errors" }
+
+func (dmu dummyMultiUnwrapper) Unwrap() []error { return dmu.errs }
+
 type dummyUnwrapper struct{ err error }
 
 func (dec dummyUnwrapper) Error() string {
@@ -65,6 +93,19 @@ func TestExitCode(t *testing.T) {
 		}
 	})
 
+	t.Run("err Error value", func(t *testing.T) {
+		// Only *Error implements the error interface, so a value Error is
+		// passed by its address, and both forms report the same exit code.
+		ee := *goerr.New("some error").WithExitCode(5)
+
+		if got, want := ee.ExitCode(), 5; got != want {
+			t.Errorf("GOT: %v; WANT: %v", got, want)
+		}
+		if got, want := goerr.ExitCode(&ee), 5; got != want {
+			t.Errorf("GOT: %v; WANT: %v", got, want)
+		}
+	})
+
 	t.Run("err exitCoderer", func(t *testing.T) {
 		err := &dummyExitCoder{code: 42}
 
@@ -73,6 +114,30 @@ func TestExitCode(t *testing.T) {
 		}
 	})
 
+	t.Run("err exitStatuser", func(t *testing.T) {
+		err := &dummyUnwrapper{err: &dummyExitStatuser{status: 42}}
+
+		if got, want := goerr.ExitCode(err), 42; got != want {
+			t.Errorf("GOT: %v; WANT: %v", got, want)
+		}
+	})
+
+	t.Run("err syser", func(t *testing.T) {
+		err := &dummySyser{sys: dummyExitStatuser{status: 42}}
+
+		if got, want := goerr.ExitCode(err), 42; got != want {
+			t.Errorf("GOT: %v; WANT: %v", got, want)
+		}
+	})
+
+	t.Run("err syser sans exit status", func(t *testing.T) {
+		err := &dummySyser{sys: "not a wait status"}
+
+		if got, want := goerr.ExitCode(err), 0; got != want {
+			t.Errorf("GOT: %v; WANT: %v", got, want)
+		}
+	})
+
 	t.Run("err unwrapper nil", func(t *testing.T) {
 		err := &dummyUnwrapper{}
 
@@ -98,6 +163,45 @@ func TestExitCode(t *testing.T) {
 	})
 }
 
+func TestExitCodeOverride(t *testing.T) {
+	defer func(fn func(error) (int, bool)) { goerr.ExitCodeOverride = fn }(goerr.ExitCodeOverride)
+	goerr.ExitCodeOverride = func(err error) (int, bool) {
+		if errors.Is(err, errOverridden) {
+			return 99, true
+		}
+		return 0, false
+	}
+
+	t.Run("override wins", func(t *testing.T) {
+		err := goerr.Wrap(errOverridden).WithExitCode(42)
+
+		if got, want := goerr.ExitCode(err), 99; got != want {
+			t.Errorf("GOT: %v; WANT: %v", got, want)
+		}
+	})
+
+	t.Run("override declines", func(t *testing.T) {
+		err := goerr.New("some error").WithExitCode(42)
+
+		if got, want := goerr.ExitCode(err), 42; got != want {
+			t.Errorf("GOT: %v; WANT: %v", got, want)
+		}
+	})
+
+	t.Run("ShouldExit", func(t *testing.T) {
+		code, exit := goerr.ShouldExit(goerr.Wrap(errOverridden).WithExitCode(42))
+
+		if got, want := exit, true; got != want {
+			t.Errorf("GOT: %v; WANT: %v", got, want)
+		}
+		if got, want := code, 99; got != want {
+			t.Errorf("GOT: %v; WANT: %v", got, want)
+		}
+	})
+}
+
+var errOverridden = errors.New("overridden")
+
 func TestTemporary(t *testing.T) {
 	t.Run("err nil", func(t *testing.T) {
 		var err error
@@ -179,3 +283,468 @@ func TestTemporary(t *testing.T) {
 		}
 	})
 }
+
+func TestTimeout(t *testing.T) {
+	t.Run("err nil", func(t *testing.T) {
+		if got, want := goerr.Timeout(nil), false; got != want {
+			t.Errorf("GOT: %v; WANT: %v", got, want)
+		}
+	})
+
+	t.Run("wrapped timeouter", func(t *testing.T) {
+		err := goerr.Wrapf(&dummyUnwrapper{err: &dummyTimeouter{timeout: true}}, "cannot dial")
+
+		if got, want := goerr.Timeout(err), true; got != want {
+			t.Errorf("GOT: %v; WANT: %v", got, want)
+		}
+	})
+
+	t.Run("sans timeouter", func(t *testing.T) {
+		err := goerr.Wrap(errors.New("some error"))
+
+		if got, want := goerr.Timeout(err), false; got != want {
+			t.Errorf("GOT: %v; WANT: %v", got, want)
+		}
+	})
+}
+
+func TestRootCause(t *testing.T) {
+	t.Run("err nil", func(t *testing.T) {
+		if got, want := goerr.RootCause(nil), error(nil); got != want {
+			t.Errorf("GOT: %v; WANT: %v", got, want)
+		}
+	})
+
+	t.Run("err *Error nil", func(t *testing.T) {
+		var err *goerr.Error
+
+		if got, want := goerr.RootCause(err), error(nil); got != want {
+			t.Errorf("GOT: %v; WANT: %v", got, want)
+		}
+	})
+
+	t.Run("err default", func(t *testing.T) {
+		err := errors.New("no unwrap")
+
+		if got, want := goerr.RootCause(err), err; got != want {
+			t.Errorf("GOT: %v; WANT: %v", got, want)
+		}
+	})
+
+	t.Run("err three levels", func(t *testing.T) {
+		base := errors.New("base")
+		err := goerr.Wrapf(&dummyUnwrapper{err: goerr.Wrap(base)}, "outer")
+
+		if got, want := goerr.RootCause(err), base; got != want {
+			t.Errorf("GOT: %v; WANT: %v", got, want)
+		}
+	})
+
+	t.Run("err unwrapper nil", func(t *testing.T) {
+		err := &dummyUnwrapper{}
+
+		if got, want := goerr.RootCause(err), error(err); got != want {
+			t.Errorf("GOT: %v; WANT: %v", got, want)
+		}
+	})
+
+	t.Run("err joined", func(t *testing.T) {
+		base := errors.New("base")
+		err := goerr.Wrap(errors.Join(base, errors.New("other")))
+
+		if got, want := goerr.RootCause(err), base; got != want {
+			t.Errorf("GOT: %v; WANT: %v", got, want)
+		}
+	})
+}
+
+func TestCyclicChain(t *testing.T) {
+	a := &dummyUnwrapper{}
+	b := &dummyUnwrapper{err: a}
+	a.err = b
+
+	if got, want := goerr.ExitCode(a), 0; got != want {
+		t.Errorf("GOT: %v; WANT: %v", got, want)
+	}
+	if got, want := goerr.Temporary(a), false; got != want {
+		t.Errorf("GOT: %v; WANT: %v", got, want)
+	}
+	if got := goerr.RootCause(a); got != error(a) && got != error(b) {
+		t.Errorf("GOT: %p; WANT: %p or %p", got, a, b)
+	}
+	if _, ok := goerr.StackTrace(a); ok {
+		t.Errorf("GOT: %v; WANT: %v", ok, false)
+	}
+}
+
+func TestRepeatedAndCyclicJoins(t *testing.T) {
+	t.Run("repeated", func(t *testing.T) {
+		// Each level wraps the level below twice, so visiting every path
+		// would take 2^50 steps.
+		var err error = &dummyExitCoder{code: 42}
+		for i := 0; i < 50; i++ {
+			err = errors.Join(err, err)
+		}
+
+		if got, want := goerr.InnermostExitCode(err), 42; got != want {
+			t.Errorf("GOT: %v; WANT: %v", got, want)
+		}
+		if got, want := goerr.AnyTemporary(err), false; got != want {
+			t.Errorf("GOT: %v; WANT: %v", got, want)
+		}
+	})
+
+	t.Run("cyclic", func(t *testing.T) {
+		c := &dummyMultiUnwrapper{}
+		c.errs = []error{c, c}
+
+		if got, want := goerr.InnermostExitCode(c), 0; got != want {
+			t.Errorf("GOT: %v; WANT: %v", got, want)
+		}
+		if got := goerr.Find(c, func(error) bool { return false }); got != nil {
+			t.Errorf("GOT: %v; WANT: nil", got)
+		}
+	})
+}
+
+func TestCause(t *testing.T) {
+	t.Run("err nil", func(t *testing.T) {
+		if got, want := goerr.Cause(nil), error(nil); got != want {
+			t.Errorf("GOT: %v; WANT: %v", got, want)
+		}
+	})
+
+	t.Run("err *Error nil", func(t *testing.T) {
+		var err *goerr.Error
+
+		if got, want := goerr.Cause(err), error(nil); got != want {
+			t.Errorf("GOT: %v; WANT: %v", got, want)
+		}
+	})
+
+	t.Run("err default", func(t *testing.T) {
+		if got, want := goerr.Cause(errors.New("no unwrap")), error(nil); got != want {
+			t.Errorf("GOT: %v; WANT: %v", got, want)
+		}
+	})
+
+	t.Run("err two levels", func(t *testing.T) {
+		base := errors.New("base")
+		middle := &dummyUnwrapper{err: base}
+		err := goerr.Wrap(middle)
+
+		if got, want := goerr.Cause(err), error(middle); got != want {
+			t.Errorf("GOT: %v; WANT: %v", got, want)
+		}
+		if got, want := goerr.Cause(middle), base; got != want {
+			t.Errorf("GOT: %v; WANT: %v", got, want)
+		}
+	})
+
+	t.Run("err joined", func(t *testing.T) {
+		base := errors.New("base")
+
+		if got, want := goerr.Cause(errors.Join(base, errors.New("other"))), base; got != want {
+			t.Errorf("GOT: %v; WANT: %v", got, want)
+		}
+	})
+}
+
+func TestShouldExit(t *testing.T) {
+	t.Run("err nil", func(t *testing.T) {
+		code, exit := goerr.ShouldExit(nil)
+
+		if got, want := exit, false; got != want {
+			t.Errorf("GOT: %v; WANT: %v", got, want)
+		}
+		if got, want := code, 0; got != want {
+			t.Errorf("GOT: %v; WANT: %v", got, want)
+		}
+	})
+
+	t.Run("nil Error", func(t *testing.T) {
+		var ee *goerr.Error
+		code, exit := goerr.ShouldExit(ee)
+
+		if got, want := exit, false; got != want {
+			t.Errorf("GOT: %v; WANT: %v", got, want)
+		}
+		if got, want := code, 0; got != want {
+			t.Errorf("GOT: %v; WANT: %v", got, want)
+		}
+	})
+
+	t.Run("err sans exit code", func(t *testing.T) {
+		code, exit := goerr.ShouldExit(goerr.New("some error"))
+
+		if got, want := exit, true; got != want {
+			t.Errorf("GOT: %v; WANT: %v", got, want)
+		}
+		if got, want := code, 1; got != want {
+			t.Errorf("GOT: %v; WANT: %v", got, want)
+		}
+	})
+
+	t.Run("err with exit code", func(t *testing.T) {
+		code, exit := goerr.ShouldExit(goerr.New("some error").WithExitCode(42))
+
+		if got, want := exit, true; got != want {
+			t.Errorf("GOT: %v; WANT: %v", got, want)
+		}
+		if got, want := code, 42; got != want {
+			t.Errorf("GOT: %v; WANT: %v", got, want)
+		}
+	})
+
+	t.Run("wrapped exit coder", func(t *testing.T) {
+		code, exit := goerr.ShouldExit(goerr.Wrapf(&dummyExitCoder{code: 42}, "ctx"))
+
+		if got, want := exit, true; got != want {
+			t.Errorf("GOT: %v; WANT: %v", got, want)
+		}
+		if got, want := code, 42; got != want {
+			t.Errorf("GOT: %v; WANT: %v", got, want)
+		}
+	})
+}
+
+func TestAnyTemporary(t *testing.T) {
+	t.Run("err nil", func(t *testing.T) {
+		if got, want := goerr.AnyTemporary(nil), false; got != want {
+			t.Errorf("GOT: %v; WANT: %v", got, want)
+		}
+	})
+
+	t.Run("err sans temporary", func(t *testing.T) {
+		err := goerr.Wrap(&dummyUnwrapper{err: &dummyTemporaryer{temporary: false}})
+
+		if got, want := goerr.AnyTemporary(err), false; got != want {
+			t.Errorf("GOT: %v; WANT: %v", got, want)
+		}
+	})
+
+	t.Run("inner temporary hidden by outer", func(t *testing.T) {
+		err := goerr.Wrap(&dummyUnwrapper{err: &dummyTemporaryer{temporary: true}}).
+			WithTemporary(false)
+
+		if got, want := goerr.Temporary(err), false; got != want {
+			t.Errorf("GOT: %v; WANT: %v", got, want)
+		}
+		if got, want := goerr.AnyTemporary(err), true; got != want {
+			t.Errorf("GOT: %v; WANT: %v", got, want)
+		}
+	})
+
+	t.Run("joined temporary", func(t *testing.T) {
+		err := goerr.Wrap(errors.Join(errors.New("other"), &dummyTemporaryer{temporary: true}))
+
+		if got, want := goerr.AnyTemporary(err), true; got != want {
+			t.Errorf("GOT: %v; WANT: %v", got, want)
+		}
+	})
+}
+
+func TestOutermostAndInnermostExitCode(t *testing.T) {
+	t.Run("err nil", func(t *testing.T) {
+		if got, want := goerr.OutermostExitCode(nil), 0; got != want {
+			t.Errorf("GOT: %v; WANT: %v", got, want)
+		}
+		if got, want := goerr.InnermostExitCode(nil), 0; got != want {
+			t.Errorf("GOT: %v; WANT: %v", got, want)
+		}
+	})
+
+	t.Run("both set", func(t *testing.T) {
+		err := goerr.Wrap(
+			goerr.Wrap(&dummyUnwrapper{err: &dummyExitCoder{code: 42}}),
+		).WithExitCode(13)
+
+		if got, want := goerr.OutermostExitCode(err), 13; got != want {
+			t.Errorf("GOT: %v; WANT: %v", got, want)
+		}
+		if got, want := goerr.InnermostExitCode(err), 42; got != want {
+			t.Errorf("GOT: %v; WANT: %v", got, want)
+		}
+	})
+
+	t.Run("outer unset", func(t *testing.T) {
+		err := goerr.Wrap(goerr.Wrap(&dummyExitCoder{code: 42}).WithExitCode(13))
+
+		if got, want := goerr.OutermostExitCode(err), 13; got != want {
+			t.Errorf("GOT: %v; WANT: %v", got, want)
+		}
+		if got, want := goerr.InnermostExitCode(err), 42; got != want {
+			t.Errorf("GOT: %v; WANT: %v", got, want)
+		}
+	})
+}
+
+func TestExitCodeByPattern(t *testing.T) {
+	patterns := map[string]int{
+		"permission denied": 77,
+		"denied":            1,
+		"no such file":      66,
+	}
+
+	t.Run("err nil", func(t *testing.T) {
+		if got, want := goerr.ExitCodeByPattern(nil, patterns), 0; got != want {
+			t.Errorf("GOT: %v; WANT: %v", got, want)
+		}
+	})
+
+	t.Run("matching", func(t *testing.T) {
+		err := fmt.Errorf("open /etc/shadow: %s", "permission denied")
+
+		if got, want := goerr.ExitCodeByPattern(err, patterns), 77; got != want {
+			t.Errorf("GOT: %v; WANT: %v", got, want)
+		}
+	})
+
+	t.Run("non-matching falls back", func(t *testing.T) {
+		err := goerr.New("some error").WithExitCode(13)
+
+		if got, want := goerr.ExitCodeByPattern(err, patterns), 13; got != want {
+			t.Errorf("GOT: %v; WANT: %v", got, want)
+		}
+	})
+}
+
+type dummyExitCoderTemporaryer struct {
+	code      int
+	temporary bool
+}
+
+func (dect dummyExitCoderTemporaryer) Error() string { return "returns exit code and temporary" }
+
+func (dect dummyExitCoderTemporaryer) ExitCode() int { return dect.code }
+
+func (dect dummyExitCoderTemporaryer) Temporary() bool { return dect.temporary }
+
+func TestAttributes(t *testing.T) {
+	for name, err := range map[string]error{
+		"err nil":                      nil,
+		"err *Error nil":               (*goerr.Error)(nil),
+		"err *Error sans attributes":   goerr.New("some error"),
+		"err *Error with attributes":   goerr.New("some error").WithExitCode(13).WithTemporary(true),
+		"err *Error with exit code":    goerr.New("some error").WithExitCode(13),
+		"err exit coder":               &dummyExitCoder{code: 42},
+		"err temporaryer":              &dummyTemporaryer{temporary: true},
+		"err both":                     &dummyExitCoderTemporaryer{code: 42, temporary: true},
+		"err unwrapper nil":            &dummyUnwrapper{},
+		"err unwrapper exit coder":     &dummyUnwrapper{err: &dummyExitCoder{code: 42}},
+		"err exit coder at two depths": &dummyUnwrapper{err: &dummyExitCoderTemporaryer{code: 42}},
+		"err default":                  errors.New("no attributes"),
+	} {
+		t.Run(name, func(t *testing.T) {
+			exitCode, temporary := goerr.Attributes(err)
+
+			if got, want := exitCode, goerr.ExitCode(err); got != want {
+				t.Errorf("GOT: %v; WANT: %v", got, want)
+			}
+			if got, want := temporary, goerr.Temporary(err); got != want {
+				t.Errorf("GOT: %v; WANT: %v", got, want)
+			}
+		})
+	}
+}
+
+func TestFind(t *testing.T) {
+	hasExitCode := func(err error) bool {
+		_, ok := err.(interface{ ExitCode() int })
+		return ok && goerr.ExitCode(err) != 0
+	}
+
+	t.Run("err nil", func(t *testing.T) {
+		if got, want := goerr.Find(nil, hasExitCode), error(nil); got != want {
+			t.Errorf("GOT: %v; WANT: %v", got, want)
+		}
+	})
+
+	t.Run("not found", func(t *testing.T) {
+		err := goerr.Wrap(&dummyUnwrapper{err: errors.New("base")})
+
+		if got, want := goerr.Find(err, hasExitCode), error(nil); got != want {
+			t.Errorf("GOT: %v; WANT: %v", got, want)
+		}
+	})
+
+	t.Run("found", func(t *testing.T) {
+		target := &dummyExitCoder{code: 42}
+		err := goerr.Wrap(&dummyUnwrapper{err: errors.Join(errors.New("other"), target)})
+
+		if got, want := goerr.Find(err, hasExitCode), error(target); got != want {
+			t.Errorf("GOT: %v; WANT: %v", got, want)
+		}
+	})
+}
+
+func TestIsAny(t *testing.T) {
+	errFirst := errors.New("first")
+	errSecond := errors.New("second")
+	errThird := errors.New("third")
+
+	err := goerr.Wrap(errors.Join(errors.New("other"), fmt.Errorf("wrapped: %w", errSecond)))
+
+	t.Run("match", func(t *testing.T) {
+		if got, want := goerr.IsAny(err, errFirst, errSecond), true; got != want {
+			t.Errorf("GOT: %v; WANT: %v", got, want)
+		}
+	})
+
+	t.Run("no match", func(t *testing.T) {
+		if got, want := goerr.IsAny(err, errFirst, errThird), false; got != want {
+			t.Errorf("GOT: %v; WANT: %v", got, want)
+		}
+	})
+}
+
+func TestIsWarning(t *testing.T) {
+	t.Run("warning", func(t *testing.T) {
+		ee := goerr.Wrap(&dummyExitCoder{code: 42}).WithWarning("using default config")
+		err := fmt.Errorf("starting: %w", ee)
+
+		if got, want := goerr.IsWarning(err), true; got != want {
+			t.Errorf("GOT: %v; WANT: %v", got, want)
+		}
+		if got, want := goerr.ExitCode(err), 0; got != want {
+			t.Errorf("GOT: %v; WANT: %v", got, want)
+		}
+		if got, want := ee.Error(), "warning: using default config: returns exit code: 42"; got != want {
+			t.Errorf("GOT: %q; WANT: %q", got, want)
+		}
+	})
+
+	t.Run("failure", func(t *testing.T) {
+		err := goerr.New("cannot open config").WithExitCode(1)
+
+		if got, want := goerr.IsWarning(err), false; got != want {
+			t.Errorf("GOT: %v; WANT: %v", got, want)
+		}
+	})
+
+	t.Run("err nil", func(t *testing.T) {
+		if got, want := goerr.IsWarning(nil), false; got != want {
+			t.Errorf("GOT: %v; WANT: %v", got, want)
+		}
+	})
+}
+
+func TestReduceExitCodes(t *testing.T) {
+	sum := func(acc, code int) int { return acc + code }
+
+	t.Run("err nil", func(t *testing.T) {
+		if got, want := goerr.ReduceExitCodes(nil, sum, 0), 0; got != want {
+			t.Errorf("GOT: %v; WANT: %v", got, want)
+		}
+	})
+
+	t.Run("sum", func(t *testing.T) {
+		err := goerr.Wrap(
+			goerr.Wrap(&dummyUnwrapper{err: &dummyExitCoder{code: 42}}),
+		).WithExitCode(13)
+
+		if got, want := goerr.ReduceExitCodes(err, sum, 0), 55; got != want {
+			t.Errorf("GOT: %v; WANT: %v", got, want)
+		}
+	})
+}
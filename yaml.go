@@ -0,0 +1,7 @@
+package goerr
+
+// MarshalYAML returns the map returned by ToMap. It implements the Marshaler
+// interface of gopkg.in/yaml.v3 without requiring this package to import it.
+func (e Error) MarshalYAML() (any, error) {
+	return e.ToMap(), nil
+}
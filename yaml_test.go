@@ -0,0 +1,62 @@
+package goerr_test
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/karrick/goerr"
+)
+
+func TestMarshalYAML(t *testing.T) {
+	t.Run("sans optionals", func(t *testing.T) {
+		v, err := goerr.New("some error message").MarshalYAML()
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		m, ok := v.(map[string]any)
+		if !ok {
+			t.Fatalf("GOT: %T; WANT: map[string]any", v)
+		}
+		if got, want := len(m), 1; got != want {
+			t.Errorf("GOT: %v; WANT: %v", got, want)
+		}
+		if got, want := m["message"], any("some error message"); got != want {
+			t.Errorf("GOT: %v; WANT: %v", got, want)
+		}
+	})
+
+	t.Run("with optionals", func(t *testing.T) {
+		v, err := goerr.Wrapf(fmt.Errorf("foo: %v", "bar"), "cannot configure").
+			WithExitCode(13).
+			WithTemporary(false).
+			WithHelpURL("https://example.com/config").
+			MarshalYAML()
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		m, ok := v.(map[string]any)
+		if !ok {
+			t.Fatalf("GOT: %T; WANT: map[string]any", v)
+		}
+		if got, want := len(m), 5; got != want {
+			t.Errorf("GOT: %v; WANT: %v", got, want)
+		}
+		if got, want := m["message"], any("cannot configure"); got != want {
+			t.Errorf("GOT: %v; WANT: %v", got, want)
+		}
+		if got, want := m["exit_code"], any(13); got != want {
+			t.Errorf("GOT: %v; WANT: %v", got, want)
+		}
+		if got, want := m["temporary"], any(false); got != want {
+			t.Errorf("GOT: %v; WANT: %v", got, want)
+		}
+		if got, want := m["help_url"], any("https://example.com/config"); got != want {
+			t.Errorf("GOT: %v; WANT: %v", got, want)
+		}
+		if got, want := m["cause"], any("foo: bar"); got != want {
+			t.Errorf("GOT: %v; WANT: %v", got, want)
+		}
+	})
+}